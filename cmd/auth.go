@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/viquitorreis/my-grpc-go-client/internal/auth"
+	"github.com/viquitorreis/my-grpc-go-client/internal/config"
+	"github.com/viquitorreis/my-grpc-go-client/internal/interceptor"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// authDialOptions sends the credentials of cfg with the calls, if any are configured.
+func authDialOptions(cfg config.Auth) []grpc.DialOption {
+	creds, err := newPerRPCCredentials(cfg)
+	if err != nil {
+		fatalln("Failed to create credentials: ", err)
+	}
+
+	if creds == nil {
+		return nil
+	}
+
+	return []grpc.DialOption{grpc.WithPerRPCCredentials(creds)}
+}
+
+func newPerRPCCredentials(cfg config.Auth) (credentials.PerRPCCredentials, error) {
+	var (
+		creds credentials.PerRPCCredentials
+		err   error
+	)
+
+	switch cfg.Type {
+	case "":
+		return nil, nil
+	case "bearer":
+		creds = auth.Bearer(cfg.Token, cfg.RequireTLS)
+	case "bearer_file":
+		creds, err = auth.NewFileToken(cfg.TokenFile, cfg.RequireTLS)
+	case "api_key":
+		creds = auth.APIKey(cfg.Header, cfg.APIKey, cfg.RequireTLS)
+	case "jwt":
+		creds, err = newJWT(cfg)
+	case "oauth2":
+		creds, err = newOAuth2(cfg)
+	default:
+		err = fmt.Errorf("unknown auth type %q", cfg.Type)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(cfg.Methods) > 0 {
+		creds = auth.ForMethods(creds, interceptor.NewMethodMatcher(cfg.Methods).Match)
+	}
+
+	return creds, nil
+}
+
+func newJWT(cfg config.Auth) (*auth.JWT, error) {
+	settings := auth.JWTSettings{
+		Algorithm:     cfg.JWT.Algorithm,
+		Secret:        []byte(cfg.JWT.Secret),
+		KeyID:         cfg.JWT.KeyID,
+		Issuer:        cfg.JWT.Issuer,
+		Subject:       cfg.JWT.Subject,
+		Audience:      cfg.JWT.Audience,
+		Claims:        cfg.JWT.Claims,
+		TTL:           cfg.JWT.TTL,
+		RefreshBefore: cfg.JWT.RefreshBefore,
+		RequireTLS:    cfg.RequireTLS,
+	}
+
+	if cfg.JWT.SecretFile != "" {
+		b, err := os.ReadFile(cfg.JWT.SecretFile)
+		if err != nil {
+			return nil, err
+		}
+
+		settings.Secret = []byte(strings.TrimSpace(string(b)))
+	}
+
+	if cfg.JWT.PrivateKeyFile != "" {
+		b, err := os.ReadFile(cfg.JWT.PrivateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+
+		if settings.PrivateKey, err = auth.ParseRSAPrivateKey(b); err != nil {
+			return nil, fmt.Errorf("%v: %w", cfg.JWT.PrivateKeyFile, err)
+		}
+	}
+
+	return auth.NewJWT(settings)
+}
+
+func newOAuth2(cfg config.Auth) (*auth.OAuth2, error) {
+	secret := cfg.OAuth2.ClientSecret
+
+	if cfg.OAuth2.ClientSecretFile != "" {
+		b, err := os.ReadFile(cfg.OAuth2.ClientSecretFile)
+		if err != nil {
+			return nil, err
+		}
+
+		secret = strings.TrimSpace(string(b))
+	}
+
+	return auth.NewOAuth2(auth.OAuth2Settings{
+		TokenURL:      cfg.OAuth2.TokenURL,
+		ClientID:      cfg.OAuth2.ClientID,
+		ClientSecret:  secret,
+		Scopes:        cfg.OAuth2.Scopes,
+		Params:        cfg.OAuth2.Params,
+		SecretInBody:  cfg.OAuth2.SecretInBody,
+		RefreshBefore: cfg.OAuth2.RefreshBefore,
+		Retries:       cfg.OAuth2.Retries,
+		Backoff:       cfg.OAuth2.Backoff,
+		Timeout:       cfg.OAuth2.Timeout,
+		RequireTLS:    cfg.RequireTLS,
+	}), nil
+}
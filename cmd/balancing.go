@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/viquitorreis/my-grpc-go-client/internal/balancing"
+	"github.com/viquitorreis/my-grpc-go-client/internal/config"
+	"github.com/viquitorreis/my-grpc-go-client/internal/fileresolver"
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// endpointsScheme resolves the endpoints of the balancing config.
+const endpointsScheme = "endpoints"
+
+// balancingDialOptions selects the balancing policy of cfg. With endpoints, the returned
+// target resolves to them and keeps target as its authority.
+func balancingDialOptions(cfg config.Balancing, target string) (string, []grpc.DialOption) {
+	serviceConfig, err := balancing.ServiceConfig(balancing.Settings{
+		Policy:        cfg.Policy,
+		HealthCheck:   cfg.HealthCheck.Enabled,
+		HealthService: cfg.HealthCheck.Service,
+	})
+	if err != nil {
+		fatalln("Failed to configure balancing: ", err)
+	}
+
+	opts := []grpc.DialOption{grpc.WithDefaultServiceConfig(serviceConfig)}
+
+	if len(cfg.Endpoints) == 0 {
+		return target, opts
+	}
+
+	opts = append(opts, grpc.WithResolvers(balancing.StaticResolver(endpointsScheme, endpoints(cfg.Endpoints))))
+
+	return endpointsScheme + ":///" + target, opts
+}
+
+func endpoints(cfg []config.Endpoint) []balancing.Endpoint {
+	endpoints := make([]balancing.Endpoint, 0, len(cfg))
+	for _, e := range cfg {
+		endpoints = append(endpoints, balancing.Endpoint{Address: e.Address, Weight: e.Weight})
+	}
+
+	return endpoints
+}
+
+// runHealthCommand checks the grpc.health.v1 status of every endpoint, those of the file
+// of a file:// target included, and fails unless all are SERVING:
+//
+//	my-grpc-client -config client.yaml health [-service bank.BankService]
+func runHealthCommand(cfg *config.Config, args []string) {
+	fs := flag.NewFlagSet("health", flag.ExitOnError)
+	service := fs.String("service", cfg.Balancing.HealthCheck.Service, "service to check, empty for the whole server")
+	timeout := fs.Duration("timeout", 5*time.Second, "deadline of each check")
+	fs.Parse(args)
+
+	addresses, err := healthAddresses(cfg)
+	if err != nil {
+		fatalln("Failed to read the endpoints: ", err)
+	}
+
+	opts := append(authDialOptions(cfg.Auth), transportDialOption(cfg.TLS))
+	healthy := true
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ENDPOINT\tSTATUS\tLATENCY")
+
+	for _, addr := range addresses {
+		status, latency, err := checkHealth(addr, *service, *timeout, opts)
+		if err != nil {
+			status = err.Error()
+		}
+
+		healthy = healthy && status == healthpb.HealthCheckResponse_SERVING.String()
+
+		fmt.Fprintf(tw, "%v\t%v\t%v\n", addr, status, latency.Round(time.Microsecond))
+	}
+
+	tw.Flush()
+
+	if !healthy {
+		exit(1)
+	}
+}
+
+// healthAddresses are the endpoints of the balancing config or of a file:// target, else the target.
+func healthAddresses(cfg *config.Config) ([]string, error) {
+	var addresses []string
+
+	for _, e := range cfg.Balancing.Endpoints {
+		addresses = append(addresses, e.Address)
+	}
+
+	if u, err := url.Parse(cfg.Target); err == nil && u.Scheme == fileresolver.Scheme && len(addresses) == 0 {
+		f, err := fileresolver.ReadFile(u.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, e := range f.Endpoints {
+			addresses = append(addresses, e.Address)
+		}
+	}
+
+	if len(addresses) == 0 {
+		addresses = append(addresses, cfg.Target)
+	}
+
+	return addresses, nil
+}
+
+func checkHealth(addr, service string, timeout time.Duration, opts []grpc.DialOption) (string, time.Duration, error) {
+	conn, err := grpc.NewClient(addr, opts...)
+	if err != nil {
+		return "", 0, err
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	start := time.Now()
+
+	res, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+	if err != nil {
+		return "", time.Since(start), err
+	}
+
+	return res.GetStatus().String(), time.Since(start), nil
+}
@@ -0,0 +1,378 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"log/slog"
+	"path"
+	"time"
+
+	"github.com/sony/gobreaker"
+	"github.com/viquitorreis/my-grpc-go-client/internal/capture"
+	"github.com/viquitorreis/my-grpc-go-client/internal/config"
+	"github.com/viquitorreis/my-grpc-go-client/internal/interceptor"
+	protoBank "github.com/viquitorreis/my-grpc-proto/protogen/go/bank"
+	reslProto "github.com/viquitorreis/my-grpc-proto/protogen/go/resiliency"
+)
+
+// newChainRegistry registers the interceptors the interceptors section of the config can use.
+// Their options default to the values the client used before the chain was configurable.
+func newChainRegistry(cfg *config.Config) *interceptor.ChainRegistry {
+	r := interceptor.NewChainRegistry()
+
+	r.Register("propagation", func(decode func(any) error) (interceptor.Chainable, error) {
+		settings := interceptor.PropagationSettings{
+			Keys:     cfg.Metadata.Propagate.Keys,
+			Prefixes: cfg.Metadata.Propagate.Prefixes,
+		}
+
+		return interceptor.Chainable{
+			Unary:  interceptor.PropagationUnaryClientInterceptor(settings),
+			Stream: interceptor.PropagationStreamClientInterceptor(settings),
+		}, nil
+	})
+
+	r.Register("metadata", func(decode func(any) error) (interceptor.Chainable, error) {
+		providers := interceptor.NewMetadataProviders(cfg.Metadata, version)
+
+		return interceptor.Chainable{
+			Unary:  interceptor.MetadataUnaryClientInterceptor(providers...),
+			Stream: interceptor.MetadataStreamClientInterceptor(providers...),
+		}, nil
+	})
+
+	r.Register("tracing", func(decode func(any) error) (interceptor.Chainable, error) {
+		if clientTracing == nil {
+			return interceptor.Chainable{}, nil
+		}
+
+		return interceptor.Chainable{
+			Unary:  clientTracing.UnaryClientInterceptor(),
+			Stream: clientTracing.StreamClientInterceptor(),
+		}, nil
+	})
+
+	r.Register("logging", func(decode func(any) error) (interceptor.Chainable, error) {
+		options := struct {
+			Level slog.Level `yaml:"level"`
+		}{Level: slog.LevelInfo}
+
+		if err := decode(&options); err != nil {
+			return interceptor.Chainable{}, err
+		}
+
+		callLogger := interceptor.NewCallLogger(interceptor.CallLoggerSettings{
+			Level:       options.Level,
+			Methods:     cfg.Logging.Methods,
+			SampleRates: cfg.Logging.Sample,
+			Payloads:    cfg.Logging.Payloads,
+		})
+
+		return interceptor.Chainable{
+			Unary:  callLogger.UnaryClientInterceptor(),
+			Stream: callLogger.StreamClientInterceptor(),
+		}, nil
+	})
+
+	r.Register("metrics", func(decode func(any) error) (interceptor.Chainable, error) {
+		return interceptor.Chainable{
+			Unary:  clientMetrics.UnaryClientInterceptor(),
+			Stream: clientMetrics.StreamClientInterceptor(),
+		}, nil
+	})
+
+	r.Register("fallback", func(decode func(any) error) (interceptor.Chainable, error) {
+		options := struct {
+			MaxAge  time.Duration `yaml:"max_age"`
+			Methods []string      `yaml:"methods"`
+		}{
+			MaxAge: time.Minute,
+			// bank reads that may be served from the last known response when the server is down
+			Methods: []string{
+				protoBank.BankService_GetCurrentBalance_FullMethodName,
+				protoBank.BankService_FetchExchangeRates_FullMethodName,
+			},
+		}
+
+		if err := decode(&options); err != nil {
+			return interceptor.Chainable{}, err
+		}
+
+		fallback := interceptor.NewResponseFallback(options.MaxAge, options.Methods...)
+
+		return interceptor.Chainable{
+			Unary:  fallback.UnaryClientInterceptor(),
+			Stream: fallback.StreamClientInterceptor(),
+		}, nil
+	})
+
+	r.Register("concurrency_limit", func(decode func(any) error) (interceptor.Chainable, error) {
+		options := struct {
+			Initial int `yaml:"initial"`
+			Min     int `yaml:"min"`
+			Max     int `yaml:"max"`
+		}{Initial: 10, Min: 1, Max: 100}
+
+		if err := decode(&options); err != nil {
+			return interceptor.Chainable{}, err
+		}
+
+		limiter := interceptor.NewConcurrencyLimiter(interceptor.ConcurrencyLimiterSettings{
+			InitialLimit: options.Initial,
+			MinLimit:     options.Min,
+			MaxLimit:     options.Max,
+			OnLimitChange: func(method string, from, to int) {
+				log.Printf("Concurrency limit of %v changed, from %v to %v\n", method, from, to)
+			},
+		})
+
+		clientMetrics.ConcurrencyLimiter(limiter)
+
+		return interceptor.Chainable{
+			Unary:  limiter.UnaryClientInterceptor(),
+			Stream: limiter.StreamClientInterceptor(),
+		}, nil
+	})
+
+	r.Register("transform", func(decode func(any) error) (interceptor.Chainable, error) {
+		// disable maps method patterns to the transformers turned off for them, all of them
+		// when the list is empty. The transformers are shared, so it applies to every entry.
+		options := struct {
+			Disable map[string][]string `yaml:"disable"`
+		}{}
+
+		if err := decode(&options); err != nil {
+			return interceptor.Chainable{}, err
+		}
+
+		for pattern, names := range options.Disable {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return interceptor.Chainable{}, fmt.Errorf("invalid method pattern %q", pattern)
+			}
+
+			for _, name := range names {
+				if !transformers.Registered(name) {
+					return interceptor.Chainable{}, fmt.Errorf("unknown transformer %q", name)
+				}
+			}
+
+			transformers.Disable(pattern, names...)
+		}
+
+		return interceptor.Chainable{
+			Unary:  transformers.UnaryClientInterceptor(),
+			Stream: transformers.StreamClientInterceptor(),
+		}, nil
+	})
+
+	r.Register("timeout", func(decode func(any) error) (interceptor.Chainable, error) {
+		type methodTimeout struct {
+			Timeout time.Duration `yaml:"timeout"`
+			Idle    time.Duration `yaml:"idle"`
+		}
+
+		options := struct {
+			Unary   time.Duration            `yaml:"unary"`
+			Stream  time.Duration            `yaml:"stream"`
+			Methods map[string]methodTimeout `yaml:"methods"`
+		}{
+			Unary:  5 * time.Second,
+			Stream: 15 * time.Second,
+			// long-lived streams are only cut when the server stops sending
+			Methods: map[string]methodTimeout{
+				protoBank.BankService_FetchExchangeRates_FullMethodName:                                          {Idle: 15 * time.Second},
+				protoBank.BankService_TransferMultiple_FullMethodName:                                            {Idle: 15 * time.Second},
+				reslProto.ResiliencyService_BidirectionalStreamResiliency_FullMethodName:                         {Idle: 15 * time.Second},
+				reslProto.ResiliencyWithMetadataService_BidirectionalStreamResiliencyWithMetadata_FullMethodName: {Idle: 15 * time.Second},
+			},
+		}
+
+		if err := decode(&options); err != nil {
+			return interceptor.Chainable{}, err
+		}
+
+		methods := make(map[string]interceptor.MethodTimeout, len(options.Methods))
+		for method, t := range options.Methods {
+			methods[method] = interceptor.MethodTimeout{Timeout: t.Timeout, Idle: t.Idle}
+		}
+
+		policy := interceptor.NewTimeoutPolicy(interceptor.TimeoutPolicySettings{
+			Unary:   interceptor.MethodTimeout{Timeout: options.Unary},
+			Stream:  interceptor.MethodTimeout{Timeout: options.Stream},
+			Methods: methods,
+			OnDeadlineUsage: func(usage interceptor.DeadlineUsage) {
+				log.Printf("Call %v used %v of %v (%.0f%%)\n", usage.Method, usage.Used, usage.Budget, usage.Ratio()*100)
+			},
+		})
+
+		return interceptor.Chainable{
+			Unary:  policy.UnaryClientInterceptor(),
+			Stream: policy.StreamClientInterceptor(),
+		}, nil
+	})
+
+	// calls wait for the connection to be ready, until their deadline, instead of failing fast
+	r.Register("wait_for_ready", func(decode func(any) error) (interceptor.Chainable, error) {
+		return interceptor.Chainable{
+			Unary:  interceptor.WaitForReadyUnaryClientInterceptor(),
+			Stream: interceptor.WaitForReadyStreamClientInterceptor(),
+		}, nil
+	})
+
+	r.Register("retry", func(decode func(any) error) (interceptor.Chainable, error) {
+		options := struct {
+			Max     int           `yaml:"max"`
+			Codes   []string      `yaml:"codes"`
+			Backoff string        `yaml:"backoff"`
+			Base    time.Duration `yaml:"base"`
+		}{
+			Max:     4,
+			Codes:   []string{"UNKNOWN", "INTERNAL"},
+			Backoff: "exponential",
+			Base:    500 * time.Millisecond,
+		}
+
+		if err := decode(&options); err != nil {
+			return interceptor.Chainable{}, err
+		}
+
+		retry := interceptor.RetrySettings{
+			Max: options.Max,
+			OnRetry: func(method string, attempt int, err error) {
+				log.Printf("Retrying %v, attempt %v after: %v\n", method, attempt, err)
+				clientMetrics.OnRetry(method, attempt, err)
+			},
+		}
+
+		for _, name := range options.Codes {
+			code, err := interceptor.ParseCode(name)
+			if err != nil {
+				return interceptor.Chainable{}, err
+			}
+
+			retry.Codes = append(retry.Codes, code)
+		}
+
+		switch options.Backoff {
+		case "exponential":
+			retry.Backoff = interceptor.BackoffExponential(options.Base)
+		case "linear":
+			retry.Backoff = interceptor.BackoffLinear(options.Base)
+		default:
+			return interceptor.Chainable{}, fmt.Errorf("unknown backoff %q, expected exponential or linear", options.Backoff)
+		}
+
+		return interceptor.Chainable{
+			Unary:  interceptor.RetryUnaryClientInterceptor(retry),
+			Stream: interceptor.RetryStreamClientInterceptor(retry),
+		}, nil
+	})
+
+	r.Register("circuit_breaker", func(decode func(any) error) (interceptor.Chainable, error) {
+		options := struct {
+			Name         string        `yaml:"name"`
+			MinRequests  uint32        `yaml:"min_requests"`
+			FailureRatio float64       `yaml:"failure_ratio"`
+			Timeout      time.Duration `yaml:"timeout"`
+		}{
+			Name:         "chain-circuit-breaker",
+			MinRequests:  3,
+			FailureRatio: 0.6,
+			Timeout:      4 * time.Second,
+		}
+
+		if err := decode(&options); err != nil {
+			return interceptor.Chainable{}, err
+		}
+
+		cb := gobreaker.NewCircuitBreaker(gobreaker.Settings{
+			Name: options.Name,
+			ReadyToTrip: func(counts gobreaker.Counts) bool {
+				failureRatio := float64(counts.TotalFailures) / float64(counts.Requests)
+				return counts.Requests >= options.MinRequests && failureRatio >= options.FailureRatio
+			},
+			IsSuccessful: interceptor.BreakerSuccessful,
+			Timeout:      options.Timeout,
+			OnStateChange: func(name string, from, to gobreaker.State) {
+				log.Printf("Circuit breaker %v changed state, from %v to %v\n", name, from, to)
+				clientMetrics.OnStateChange(name, from, to)
+			},
+		})
+
+		return interceptor.Chainable{
+			Unary:  interceptor.CircuitBreakerUnaryClientInterceptor(cb),
+			Stream: interceptor.CircuitBreakerStreamClientInterceptor(cb),
+		}, nil
+	})
+
+	r.Register("record", func(decode func(any) error) (interceptor.Chainable, error) {
+		if cfg.Capture.Path == "" {
+			return interceptor.Chainable{}, nil
+		}
+
+		captureWriter, err := capture.Create(cfg.Capture.Path)
+		if err != nil {
+			return interceptor.Chainable{}, fmt.Errorf("open capture file: %w", err)
+		}
+
+		atExit(func() { captureWriter.Close() })
+
+		recorder := interceptor.NewRecorder(captureWriter, interceptor.RecorderSettings{
+			Methods:      cfg.Capture.Methods,
+			MaskMetadata: cfg.Capture.MaskMetadata,
+		})
+
+		log.Println("Recording calls to", cfg.Capture.Path)
+
+		return interceptor.Chainable{
+			Unary:  recorder.UnaryClientInterceptor(),
+			Stream: recorder.StreamClientInterceptor(),
+		}, nil
+	})
+
+	r.Register("compression", func(decode func(any) error) (interceptor.Chainable, error) {
+		options := struct {
+			Codec   string `yaml:"codec"`
+			MinSize int    `yaml:"min_size"`
+		}{Codec: "gzip", MinSize: 1024}
+
+		if err := decode(&options); err != nil {
+			return interceptor.Chainable{}, err
+		}
+
+		c, err := interceptor.NewCompression(interceptor.CompressionSettings{
+			Codec:   options.Codec,
+			MinSize: options.MinSize,
+		})
+		if err != nil {
+			return interceptor.Chainable{}, err
+		}
+
+		return interceptor.Chainable{
+			Unary:  c.UnaryClientInterceptor(),
+			Stream: c.StreamClientInterceptor(),
+		}, nil
+	})
+
+	// faults are injected where the chain puts them, closest to the wire by default, so every
+	// other interceptor sees them as real failures
+	r.Register("fault_injection", func(decode func(any) error) (interceptor.Chainable, error) {
+		if !cfg.FaultInjection.Enabled {
+			return interceptor.Chainable{}, nil
+		}
+
+		faultInjector, err := interceptor.NewFaultInjector(cfg)
+		if err != nil {
+			return interceptor.Chainable{}, err
+		}
+
+		log.Println("[WARNING] fault injection is enabled")
+
+		return interceptor.Chainable{
+			Unary:  faultInjector.UnaryClientInterceptor(),
+			Stream: faultInjector.StreamClientInterceptor(),
+		}, nil
+	})
+
+	return r
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"github.com/viquitorreis/my-grpc-go-client/internal/config"
+)
+
+// runCommand dispatches `my-grpc-client [-config file] <command> [flags]`. Without a command the client runs the demo calls in main.
+func runCommand(cfg *config.Config, name string, args []string) {
+	switch name {
+	case "scenario":
+		runScenarioCommand(cfg, args)
+	case "load":
+		runLoadCommand(cfg, args)
+	case "replay":
+		runReplayCommand(cfg, args)
+	case "mock":
+		runMockCommand(cfg, args)
+	case "health":
+		runHealthCommand(cfg, args)
+	case "compression-bench":
+		runCompressionBenchCommand(cfg, args)
+	default:
+		fatalln("Unknown command:", name)
+	}
+}
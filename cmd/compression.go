@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/viquitorreis/my-grpc-go-client/internal/capture"
+	"github.com/viquitorreis/my-grpc-go-client/internal/compression"
+	"github.com/viquitorreis/my-grpc-go-client/internal/config"
+	"github.com/viquitorreis/my-grpc-go-client/internal/load"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// runCompressionBenchCommand compresses the messages of a capture file with each codec, to
+// pick codecs and thresholds for the compression interceptor:
+//
+//	my-grpc-client compression-bench [-codecs gzip] [-min-size 1024] [-rounds 20] capture.jsonl
+func runCompressionBenchCommand(cfg *config.Config, args []string) {
+	fs := flag.NewFlagSet("compression-bench", flag.ExitOnError)
+	codecs := fs.String("codecs", strings.Join(compression.Names(), ","), "comma-separated codecs to compare")
+	minSize := fs.Int("min-size", 1024, "only compress messages of at least this many bytes")
+	rounds := fs.Int("rounds", 20, "times each message is compressed, the time reported is per round")
+	format := fs.String("format", "table", "output format, table or json")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fatalln("Usage: compression-bench [-codecs names] [-min-size bytes] [-rounds n] [-format table|json] <capture.jsonl>")
+	}
+
+	calls, err := capture.ReadFile(fs.Arg(0))
+	if err != nil {
+		fatalln("Failed to read capture: ", err)
+	}
+
+	samples, err := compressionSamples(calls)
+	if err != nil {
+		fatalln("Failed to read capture: ", err)
+	}
+
+	results, err := compression.Benchmark(samples, strings.Split(*codecs, ","), *minSize, *rounds)
+	if err != nil {
+		fatalln("Failed to benchmark compression: ", err)
+	}
+
+	if *format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(results)
+	} else {
+		err = compression.WriteText(os.Stdout, results)
+	}
+
+	if err != nil {
+		fatalln("Failed to write results: ", err)
+	}
+}
+
+// compressionSamples serializes the recorded messages as they went on the wire, as far as the
+// redaction at recording left them, see capture.Entry.Redacted.
+func compressionSamples(calls []*capture.Call) ([]compression.Sample, error) {
+	var samples []compression.Sample
+	redacted := 0
+
+	for _, c := range calls {
+		method, err := load.ResolveMethod(c.Method)
+		if err != nil {
+			return nil, err
+		}
+
+		add := func(direction string, bodies []json.RawMessage, masked []string, newMsg func() proto.Message) error {
+			if len(masked) > 0 {
+				redacted += len(bodies)
+			}
+
+			for i, body := range bodies {
+				msg := newMsg()
+				if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(body, msg); err != nil {
+					return fmt.Errorf("call %v, %v message %d: %w", c.ID, direction, i, err)
+				}
+
+				payload, err := proto.Marshal(msg)
+				if err != nil {
+					return err
+				}
+
+				samples = append(samples, compression.Sample{Method: c.Method, Direction: direction, Payload: payload})
+			}
+
+			return nil
+		}
+
+		if err := add("send", c.Sent, c.SentRedacted, method.NewRequest); err != nil {
+			return nil, err
+		}
+
+		if err := add("recv", c.Received, c.ReceivedRedacted, method.NewResponse); err != nil {
+			return nil, err
+		}
+	}
+
+	// masked strings keep their length but zeroed fields are dropped, so the sizes are off
+	if redacted > 0 {
+		log.Printf("[WARNING] %d of %d messages were redacted at recording, their sizes and ratios are not the real ones\n",
+			redacted, len(samples),
+		)
+	}
+
+	return samples, nil
+}
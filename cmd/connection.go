@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+
+	"github.com/viquitorreis/my-grpc-go-client/internal/config"
+	"github.com/viquitorreis/my-grpc-go-client/internal/connection"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+)
+
+// connectionDialOptions applies the keepalive and idle settings of cfg.
+func connectionDialOptions(cfg config.Connection) []grpc.DialOption {
+	var opts []grpc.DialOption
+
+	if cfg.Keepalive.Time > 0 {
+		opts = append(opts, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                cfg.Keepalive.Time,
+			Timeout:             cfg.Keepalive.Timeout,
+			PermitWithoutStream: cfg.Keepalive.PermitWithoutStream,
+		}))
+	}
+
+	if cfg.IdleTimeout > 0 {
+		opts = append(opts, grpc.WithIdleTimeout(cfg.IdleTimeout))
+	}
+
+	return opts
+}
+
+// watchConnection logs and counts the state changes of conn, and waits for it to be ready
+// when cfg asks for a pre-flight.
+func watchConnection(cfg config.Connection, conn *grpc.ClientConn) {
+	connection.Watch(context.Background(), conn, connection.WatcherSettings{
+		OnChange: []func(connection.StateChange){
+			func(c connection.StateChange) { clientMetrics.OnConnectivityChange(c.Target, c.From, c.To) },
+		},
+	})
+
+	if cfg.ReadyTimeout <= 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ReadyTimeout)
+	defer cancel()
+
+	if err := connection.WaitUntilReady(ctx, conn); err != nil {
+		fatalln("Connection pre-flight failed: ", err)
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/viquitorreis/my-grpc-go-client/internal/config"
+	"github.com/viquitorreis/my-grpc-go-client/internal/load"
+	"google.golang.org/grpc"
+)
+
+// runLoadCommand load-tests one method:
+//
+//	my-grpc-client load -method /bank.BankService/GetCurrentBalance -data '{"account_number":"{{seq}}"}' -concurrency 10 -duration 30s
+//	my-grpc-client load -method /resiliency.ResiliencyService/BidirectionalStreamResiliency -data @request.json -qps 50 -messages 20
+func runLoadCommand(cfg *config.Config, args []string) {
+	fs := flag.NewFlagSet("load", flag.ExitOnError)
+	target := fs.String("target", cfg.Target, "address of the server")
+	method := fs.String("method", "", "full method name, e.g. /bank.BankService/GetCurrentBalance")
+	data := fs.String("data", "{}", "protojson request template, or @file to read it from a file")
+	concurrency := fs.Int("concurrency", 1, "workers of a closed-loop run")
+	qps := fs.Float64("qps", 0, "target rate of an open-loop run, overrides -concurrency")
+	maxInFlight := fs.Int("max-inflight", 1000, "calls allowed at once in an open-loop run")
+	warmup := fs.Duration("warmup", 0, "time to run before recording results")
+	duration := fs.Duration("duration", 10*time.Second, "time to record results")
+	timeout := fs.Duration("timeout", 0, "deadline of each call")
+	messages := fs.Int("messages", 1, "requests sent per client or bidi stream")
+	format := fs.String("format", "table", "output format, table or json")
+	out := fs.String("out", "", "also write the JSON report to this file")
+	list := fs.Bool("list", false, "list the known methods and exit")
+	fs.Parse(args)
+
+	if *list {
+		for _, m := range load.KnownMethods() {
+			fmt.Println(m)
+		}
+		return
+	}
+
+	if *method == "" {
+		fatalln("Usage: load -method /package.Service/Method [flags], see load -list")
+	}
+
+	// the ticker of an open-loop run has a resolution of 1ns
+	if !(*qps >= 0 && *qps <= 1e9) {
+		fatalln("Invalid -qps: must be between 0 and 1e9, got", *qps)
+	}
+
+	m, err := load.ResolveMethod(*method)
+	if err != nil {
+		fatalln("Failed to resolve method: ", err)
+	}
+
+	templateText := *data
+	if path, ok := strings.CutPrefix(templateText, "@"); ok {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			fatalln("Failed to read request template: ", err)
+		}
+
+		templateText = string(b)
+	}
+
+	tmpl, err := load.NewRequestTemplate(m, templateText)
+	if err != nil {
+		fatalln("Invalid request template: ", err)
+	}
+
+	opts := append(instrumentationDialOptions(), authDialOptions(cfg.Auth)...)
+	opts = append(opts, transportDialOption(cfg.TLS))
+	opts = append(opts, connectionDialOptions(cfg.Connection)...)
+
+	dialTarget, balancingOptions := balancingDialOptions(cfg.Balancing, *target)
+	opts = append(opts, balancingOptions...)
+
+	conn, err := grpc.NewClient(dialTarget, opts...)
+	if err != nil {
+		fatalln("Erro ao conectar com o servidor gRPC, err:", err)
+	}
+	defer conn.Close()
+
+	watchConnection(cfg.Connection, conn)
+
+	runner := load.NewRunner(conn, m, tmpl, load.Settings{
+		Concurrency: *concurrency,
+		QPS:         *qps,
+		MaxInFlight: *maxInFlight,
+		Warmup:      *warmup,
+		Duration:    *duration,
+		Timeout:     *timeout,
+		Messages:    *messages,
+	})
+
+	log.Printf("Load testing %v (%v) for %v after %v of warmup\n", m.FullName, m.Kind(), *duration, *warmup)
+
+	report := runner.Run(context.Background())
+
+	if *format == "json" {
+		err = report.WriteJSON(os.Stdout)
+	} else {
+		err = report.WriteText(os.Stdout)
+	}
+
+	if err != nil {
+		fatalln("Failed to write load report: ", err)
+	}
+
+	if *out != "" {
+		if err := writeLoadReport(report, *out); err != nil {
+			fatalln("Failed to write load report: ", err)
+		}
+	}
+}
+
+func writeLoadReport(report *load.Report, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return report.WriteJSON(f)
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+
+	"github.com/viquitorreis/my-grpc-go-client/internal/config"
+)
+
+// newLogger builds the client logger. Once set as the slog default it also receives
+// everything written with the log package.
+func newLogger(cfg config.Logging) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: cfg.Level}
+
+	if cfg.Format == "json" {
+		return slog.New(slog.NewJSONHandler(os.Stdout, opts))
+	}
+
+	return slog.New(slog.NewTextHandler(os.Stdout, opts))
+}
@@ -2,21 +2,32 @@ package main
 
 import (
 	"context"
+	"flag"
 	"log"
+	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/sony/gobreaker"
 	"github.com/viquitorreis/my-grpc-go-client/internal/adapter/hello"
 	"github.com/viquitorreis/my-grpc-go-client/internal/adapter/resiliency"
 	domainResiliency "github.com/viquitorreis/my-grpc-go-client/internal/application/domain/resiliency"
+	"github.com/viquitorreis/my-grpc-go-client/internal/config"
 	"github.com/viquitorreis/my-grpc-go-client/internal/interceptor"
+	"github.com/viquitorreis/my-grpc-go-client/internal/redact"
+	protoBank "github.com/viquitorreis/my-grpc-proto/protogen/go/bank"
 	reslProto "github.com/viquitorreis/my-grpc-proto/protogen/go/resiliency"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/proto"
 )
 
+// version is sent to the server by the client version metadata provider, set with -ldflags "-X main.version=..."
+var version = "dev"
+
 var circuitBreaker *gobreaker.CircuitBreaker
 
+var transformers *interceptor.Transformers
+
 func initCircuitBreaker() {
 	myBreaker := gobreaker.Settings{
 		Name: "my-circuit-breaker",
@@ -33,19 +44,57 @@ func initCircuitBreaker() {
 		Timeout: 4 * time.Second,
 		OnStateChange: func(name string, from, to gobreaker.State) {
 			log.Printf("Circuit breaker %v changed state, from %v to %v\n\n", name, from, to)
+			clientMetrics.OnStateChange(name, from, to)
 		},
 	}
 
 	circuitBreaker = gobreaker.NewCircuitBreaker(myBreaker)
 }
 
+func initTransformers() {
+	transformers = interceptor.NewTransformers()
+
+	trimAccountNumbers := interceptor.StringFieldsTransform("*account_number", strings.TrimSpace)
+	for _, msg := range []proto.Message{&protoBank.CurrentBalanceRequest{}, &protoBank.Transaction{}, &protoBank.TransferRequest{}} {
+		transformers.Register("trim-account-numbers", proto.MessageName(msg), interceptor.DirectionRequest, trimAccountNumbers)
+	}
+
+	upperCurrencies := interceptor.StringFieldsTransform("*currency", strings.ToUpper)
+	for _, msg := range []proto.Message{&protoBank.CreateAccountRequest{}, &protoBank.ExchangeRateRequest{}, &protoBank.TransferRequest{}} {
+		transformers.Register("uppercase-currencies", proto.MessageName(msg), interceptor.DirectionRequest, upperCurrencies)
+	}
+}
+
 func main() {
 	log.SetFlags(0)
-	log.SetOutput(&logWriter{})
+	slog.SetDefault(newLogger(config.Default().Logging))
+
+	configPath := flag.String("config", "", "path to the client YAML config")
+	flag.Parse()
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		fatalln("Failed to load config: ", err)
+	}
+
+	slog.SetDefault(newLogger(cfg.Logging))
+	redact.SetDefault(redact.New(cfg.Redaction))
+
+	startMetrics(cfg.Metrics)
+	startTracing(cfg.Tracing)
+	defer runExitHooks()
+
+	if flag.NArg() > 0 {
+		runCommand(cfg, flag.Arg(0), flag.Args()[1:])
+		return
+	}
 
 	// Create a new gRPC client
 	var opts []grpc.DialOption
-	opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	opts = append(opts, transportDialOption(cfg.TLS))
+	opts = append(opts, connectionDialOptions(cfg.Connection)...)
+	opts = append(opts, grpc.WithStatsHandler(callTiming))
+	opts = append(opts, authDialOptions(cfg.Auth)...)
 	// opts = append(
 	// 	opts,
 	// 	grpc.WithUnaryInterceptor(
@@ -71,40 +120,38 @@ func main() {
 	// )
 
 	initCircuitBreaker()
+	initTransformers()
 
-	opts = append(opts,
-		grpc.WithChainUnaryInterceptor(
-			interceptor.LogUnaryClientInterceptor(),
-			interceptor.BasicUnaryServerInterceptor(),
-			interceptor.TimeoutUnaryClientInterceptor(5*time.Second),
-		),
-	)
+	unaryInterceptors, streamInterceptors, err := newChainRegistry(cfg).Build(cfg.Interceptors)
+	if err != nil {
+		fatalln("Failed to build the interceptor chain: ", err)
+	}
 
-	opts = append(opts,
-		grpc.WithChainStreamInterceptor(
-			interceptor.LogStreamClientInterceptor(),
-			interceptor.BasicClientStreamInterceptor(),
-			interceptor.TimeoutStreamClientInterceptor(15*time.Second),
-		),
-	)
+	opts = append(opts, grpc.WithChainUnaryInterceptor(unaryInterceptors...))
+	opts = append(opts, grpc.WithChainStreamInterceptor(streamInterceptors...))
 
 	// Connect to gRPC server
-	conn, err := grpc.NewClient("localhost:9090", opts...)
+	dialTarget, balancingOptions := balancingDialOptions(cfg.Balancing, cfg.Target)
+	opts = append(opts, balancingOptions...)
+
+	conn, err := grpc.NewClient(dialTarget, opts...)
 	if err != nil {
-		log.Fatalln("Erro ao conectar com o servidor gRPC, err:", err)
+		fatalln("Erro ao conectar com o servidor gRPC, err:", err)
 	}
 	defer conn.Close()
 
+	watchConnection(cfg.Connection, conn)
+
 	helloAdapter, err := hello.NewHelloAdapter(conn)
 	if err != nil {
-		log.Fatalln("Can not create Hello Adapter: ", err)
+		fatalln("Can not create Hello Adapter: ", err)
 	}
 
 	runSayHello(helloAdapter, "Victor Reis")
 
 	resiliencyAdapter, err := resiliency.NewResiliencyAdapter(conn)
 	if err != nil {
-		log.Fatal("Erro ao criar o adapter de resiliency, err:", err)
+		fatalln("Erro ao criar o adapter de resiliency, err:", err)
 	}
 
 	// bankAdapter, err := bank.NewBankAdapter(conn)
@@ -143,12 +190,17 @@ func main() {
 }
 
 // func runGetCurrentBalance(adapter *bank.BankAdapter, account string) {
-// 	bal, err := adapter.GetCurrentBalance(context.Background(), account)
+// 	var staleInfo interceptor.StaleInfo
+// 	bal, _, err := adapter.GetCurrentBalance(context.Background(), account, interceptor.StaleResponse(&staleInfo))
 // 	if err != nil {
 // 		log.Fatalln("Erro ao chamar o serviço de bank, err:", err)
 // 	}
 
-// 	log.Println("Saldo atual da conta:", bal)
+// 	if staleInfo.Stale {
+// 		log.Printf("Saldo de %v atrás (servidor indisponível: %v)\n", staleInfo.Age, staleInfo.Cause)
+// 	}
+
+// 	log.Println("Saldo atual da conta:", redact.Message(bal))
 // }
 
 // func runFetchExchangeRates(adapter *bank.BankAdapter, fromCur, toCur string) {
@@ -181,12 +233,12 @@ func main() {
 // }
 
 func runSayHello(adapter *hello.HelloAdapter, name string) {
-	greet, err := adapter.SayHello(context.Background(), name)
+	greet, _, err := adapter.SayHello(context.Background(), name)
 	if err != nil {
-		log.Fatalln("Erro ao chamar o serviço de hello, err:", err)
+		fatalln("Erro ao chamar o serviço de hello, err:", err)
 	}
 
-	log.Println("Resposta do serviço de hello:", greet.Message)
+	log.Println("Resposta do serviço de hello:", redact.Message(greet))
 }
 
 // func runTransferMultiple(adapter *bank.BankAdapter, fromAcc, toAcc string, numDummyTransactions int) {
@@ -227,7 +279,7 @@ func runSayHello(adapter *hello.HelloAdapter, name string) {
 // 	ctx, cancel := context.WithTimeout(context.Background(), timeout) // contexto vai esperar apenas o timeout específicado no parâmetro
 // 	defer cancel()
 
-// 	res, err := adapter.UnaryResiliency(ctx, minDelaySecond, maxDelaySecond, statusCodes)
+// 	res, _, err := adapter.UnaryResiliency(ctx, minDelaySecond, maxDelaySecond, statusCodes)
 // 	if err != nil {
 // 		log.Fatalln("Erro ao chamar o serviço de resiliency, err:", err)
 // 	}
@@ -259,37 +311,40 @@ func runUnaryResiliencyWithTimeout(adapter *resiliency.ResiliencyAdapter, minDel
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
-	res, err := adapter.UnaryResiliency(ctx, minDelaySecond, maxDelaySecond, statusCodes)
+	res, _, err := adapter.UnaryResiliency(ctx, minDelaySecond, maxDelaySecond, statusCodes)
 	if err != nil {
-		log.Fatalln("Failed to call runUnaryResiliencyWithTimeout: ", err)
+		fatalln("Failed to call runUnaryResiliencyWithTimeout: ", err)
 	}
 
 	log.Println(res.DummyString)
 }
 
 func runServerStreamingResiliencyWithTimeout(adapter *resiliency.ResiliencyAdapter, minDelaySecond, maxDelaySecond int32, statusCodes []uint32, timeout time.Duration) {
-	ctx, _ := context.WithTimeout(context.Background(), timeout)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
 
 	adapter.ServerStreamingResiliency(ctx, minDelaySecond, maxDelaySecond, statusCodes)
 }
 
 func runClientStreamingResiliencyWithTimeout(adapter *resiliency.ResiliencyAdapter, minDelaySecond int32, maxDelaySecond int32, statusCodes []uint32, count int, timeout time.Duration) {
-	ctx, _ := context.WithTimeout(context.Background(), timeout)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
 
 	adapter.ClientStreamResiliency(ctx, minDelaySecond, maxDelaySecond, statusCodes, count)
 }
 
 func runBiDirectionalStreamingResiliencyWithTimeout(adapter *resiliency.ResiliencyAdapter, minDelaySecond, maxDelaySecond int32, statusCodes []uint32, count int, timeout time.Duration) {
-	ctx, _ := context.WithTimeout(context.Background(), timeout)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
 
 	adapter.BidirectionalStreamingResiliency(ctx, minDelaySecond, maxDelaySecond, statusCodes, count)
 }
 
 // Retry Pattern
 func runUnaryResiliency(adapter *resiliency.ResiliencyAdapter, minDelaySecond, maxDelaySecond int32, statusCodes []uint32) {
-	res, err := adapter.UnaryResiliency(context.Background(), minDelaySecond, maxDelaySecond, statusCodes)
+	res, _, err := adapter.UnaryResiliency(context.Background(), minDelaySecond, maxDelaySecond, statusCodes)
 	if err != nil {
-		log.Fatalln("Failed to call UnaryResiliency: ", err)
+		fatalln("Failed to call UnaryResiliency: ", err)
 	}
 
 	log.Println(res.DummyString)
@@ -310,7 +365,8 @@ func runBiDirectionalStreamingResiliency(adapter *resiliency.ResiliencyAdapter,
 func runUnaryResiliencyWithCircuitBreaker(adapter *resiliency.ResiliencyAdapter, minDelaySecond, maxDelaySecond int32, statusCodes []uint32) {
 	cBreakerRes, cBreakerErr := circuitBreaker.Execute(
 		func() (interface{}, error) {
-			return adapter.UnaryResiliency(context.Background(), minDelaySecond, maxDelaySecond, statusCodes)
+			res, _, err := adapter.UnaryResiliency(context.Background(), minDelaySecond, maxDelaySecond, statusCodes)
+			return res, err
 		},
 	)
 
@@ -322,9 +378,9 @@ func runUnaryResiliencyWithCircuitBreaker(adapter *resiliency.ResiliencyAdapter,
 }
 
 func runUnaryResiliencyWithMetadata(adapter *resiliency.ResiliencyAdapter, minDelaySecond, maxDelaySecond int32, statusCodes []uint32) {
-	res, err := adapter.UnaryResiliencyWithMetadata(context.Background(), minDelaySecond, maxDelaySecond, statusCodes)
+	res, _, err := adapter.UnaryResiliencyWithMetadata(context.Background(), minDelaySecond, maxDelaySecond, statusCodes)
 	if err != nil {
-		log.Fatalln("Failed to call runUnaryResiliencyWithMetadata: ", err)
+		fatalln("Failed to call runUnaryResiliencyWithMetadata: ", err)
 	}
 
 	log.Println(res.DummyString)
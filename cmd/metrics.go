@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/viquitorreis/my-grpc-go-client/internal/config"
+	"github.com/viquitorreis/my-grpc-go-client/internal/interceptor"
+	"github.com/viquitorreis/my-grpc-go-client/internal/metrics"
+	"github.com/viquitorreis/my-grpc-go-client/internal/timing"
+)
+
+var metricsRegistry = metrics.NewRegistry()
+
+// clientMetrics counts the calls of every connection the client opens
+var clientMetrics = interceptor.NewMetrics(metricsRegistry)
+
+// callTiming keeps the timing breakdown of the last calls of every connection the client opens
+var callTiming = timing.NewHandler(timing.Settings{
+	Level:    slog.LevelDebug,
+	Registry: metricsRegistry,
+	History:  100,
+})
+
+var exitHooks []func()
+
+// atExit registers f to run when main returns or the client exits through exit.
+func atExit(f func()) {
+	exitHooks = append(exitHooks, f)
+}
+
+func runExitHooks() {
+	for i := len(exitHooks) - 1; i >= 0; i-- {
+		exitHooks[i]()
+	}
+
+	exitHooks = nil
+}
+
+// exit is os.Exit running the exit hooks first.
+func exit(code int) {
+	runExitHooks()
+	os.Exit(code)
+}
+
+// fatalln is log.Fatalln exiting through exit, so that the exit hooks run.
+func fatalln(v ...any) {
+	log.Println(v...)
+	exit(1)
+}
+
+// startMetrics serves the metrics on cfg.Listen and dumps them to cfg.File at exit, when set.
+func startMetrics(cfg config.Metrics) {
+	if cfg.Listen != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", metricsRegistry.Handler())
+		mux.HandleFunc("/calls", serveRecentCalls)
+
+		go func() {
+			err := http.ListenAndServe(cfg.Listen, mux)
+			if err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Println("Metrics endpoint stopped: ", err)
+			}
+		}()
+
+		log.Printf("Serving metrics on http://%v/metrics and call timings on http://%v/calls\n", cfg.Listen, cfg.Listen)
+	}
+
+	if cfg.File != "" {
+		atExit(func() {
+			if err := metricsRegistry.WriteFile(cfg.File); err != nil {
+				log.Println("Failed to write metrics file: ", err)
+			}
+		})
+	}
+}
+
+// serveRecentCalls answers the timing breakdown of the last calls as JSON, ?n= of them (10 by default).
+func serveRecentCalls(w http.ResponseWriter, r *http.Request) {
+	n := 10
+	if v := r.URL.Query().Get("n"); v != "" {
+		var err error
+		if n, err = strconv.Atoi(v); err != nil || n < 0 {
+			http.Error(w, "invalid n", http.StatusBadRequest)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(callTiming.Recent(n))
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/viquitorreis/my-grpc-go-client/internal/capture"
+	"github.com/viquitorreis/my-grpc-go-client/internal/config"
+	"github.com/viquitorreis/my-grpc-go-client/internal/mock"
+)
+
+// runMockCommand serves the responses of capture files, so that the client can run without the real servers:
+//
+//	my-grpc-client mock [-listen localhost:9090] [-match body|method] capture.jsonl...
+//	my-grpc-client mock -listen unix:/tmp/mock.sock capture.jsonl
+//
+// The client then uses the mock with -config setting target to the same address (unix:///tmp/mock.sock for a socket).
+func runMockCommand(cfg *config.Config, args []string) {
+	fs := flag.NewFlagSet("mock", flag.ExitOnError)
+	listen := fs.String("listen", cfg.Mock.Listen, "host:port or unix:/path/to/socket to listen on")
+	match := fs.String("match", cfg.Mock.Match, "body to match requests with recorded ones, method to match on the method only")
+	ignore := fs.String("ignore", "", "comma-separated request fields to ignore on top of mock.ignore from the config")
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fatalln("Usage: mock [-listen address] [-match body|method] [-ignore fields] <capture.jsonl>...")
+	}
+
+	var calls []*capture.Call
+	for _, path := range fs.Args() {
+		c, err := capture.ReadFile(path)
+		if err != nil {
+			fatalln("Failed to read capture: ", err)
+		}
+
+		calls = append(calls, c...)
+	}
+
+	ignored := cfg.Mock.Ignore
+	if *ignore != "" {
+		ignored = append(ignored, strings.Split(*ignore, ",")...)
+	}
+
+	server := mock.NewServer(calls, mock.Settings{
+		Match:  mock.MatchMode(*match),
+		Ignore: ignored,
+	})
+
+	lis, err := mockListener(*listen)
+	if err != nil {
+		fatalln("Failed to listen: ", err)
+	}
+
+	for _, m := range server.Methods() {
+		log.Println("Serving recorded calls of", m)
+	}
+
+	log.Println("Mock server listening on", *listen)
+
+	if err := server.GRPCServer().Serve(lis); err != nil {
+		fatalln("Mock server failed: ", err)
+	}
+}
+
+func mockListener(address string) (net.Listener, error) {
+	path, ok := strings.CutPrefix(address, "unix:")
+	if !ok {
+		return net.Listen("tcp", address)
+	}
+
+	path = strings.TrimPrefix(path, "//")
+
+	// a socket left by a previous run would make Listen fail
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	return net.Listen("unix", path)
+}
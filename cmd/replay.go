@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"strings"
+
+	"github.com/viquitorreis/my-grpc-go-client/internal/capture"
+	"github.com/viquitorreis/my-grpc-go-client/internal/config"
+	"github.com/viquitorreis/my-grpc-go-client/internal/replay"
+	"google.golang.org/grpc"
+)
+
+// runReplayCommand re-issues the calls of a capture file and diffs the responses:
+//
+//	my-grpc-client -config client.yaml replay [-target localhost:9090] [-timing] [-ignore *.rate] capture.jsonl
+//
+// Calls whose requests had fields redacted at recording are reported as not replayable
+// instead of being sent with the masked values.
+func runReplayCommand(cfg *config.Config, args []string) {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	target := fs.String("target", cfg.Target, "address of the server")
+	timing := fs.Bool("timing", false, "keep the recorded timing between calls instead of running them back to back")
+	timeout := fs.Duration("timeout", 0, "deadline of each call")
+	ignore := fs.String("ignore", "", "comma-separated fields to ignore on top of replay.ignore from the config")
+	reportPath := fs.String("report", "", "write the report to this file (.json for JSON, text otherwise)")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fatalln("Usage: replay [-target address] [-timing] [-ignore fields] [-report file] <capture.jsonl>")
+	}
+
+	calls, err := capture.ReadFile(fs.Arg(0))
+	if err != nil {
+		fatalln("Failed to read capture: ", err)
+	}
+
+	ignored := cfg.Replay.Ignore
+	if *ignore != "" {
+		ignored = append(ignored, strings.Split(*ignore, ",")...)
+	}
+
+	opts := append(instrumentationDialOptions(), authDialOptions(cfg.Auth)...)
+	opts = append(opts, transportDialOption(cfg.TLS))
+	opts = append(opts, connectionDialOptions(cfg.Connection)...)
+
+	dialTarget, balancingOptions := balancingDialOptions(cfg.Balancing, *target)
+	opts = append(opts, balancingOptions...)
+
+	conn, err := grpc.NewClient(dialTarget, opts...)
+	if err != nil {
+		fatalln("Erro ao conectar com o servidor gRPC, err:", err)
+	}
+	defer conn.Close()
+
+	watchConnection(cfg.Connection, conn)
+
+	runner := replay.NewRunner(conn, replay.Settings{
+		Timing:  *timing,
+		Ignore:  ignored,
+		Timeout: *timeout,
+	})
+
+	report := runner.Run(context.Background(), calls)
+
+	if err := report.WriteText(os.Stdout); err != nil {
+		fatalln("Failed to write replay report: ", err)
+	}
+
+	if *reportPath != "" {
+		if err := writeReplayReport(report, *reportPath); err != nil {
+			fatalln("Failed to write replay report: ", err)
+		}
+	}
+
+	if !report.Passed() {
+		exit(1)
+	}
+}
+
+func writeReplayReport(report *replay.Report, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if strings.HasSuffix(path, ".json") {
+		return report.WriteJSON(f)
+	}
+
+	return report.WriteText(f)
+}
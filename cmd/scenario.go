@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"strings"
+
+	"github.com/viquitorreis/my-grpc-go-client/internal/config"
+	"github.com/viquitorreis/my-grpc-go-client/internal/interceptor"
+	"github.com/viquitorreis/my-grpc-go-client/internal/scenario"
+	"google.golang.org/grpc"
+)
+
+// runScenarioCommand runs resiliency scenarios from YAML files:
+//
+//	my-grpc-client scenario [-target localhost:9090] [-report report.json] scenarios/*.yaml
+func runScenarioCommand(cfg *config.Config, args []string) {
+	fs := flag.NewFlagSet("scenario", flag.ExitOnError)
+	target := fs.String("target", cfg.Target, "address of the resiliency server")
+	reportPath := fs.String("report", "", "write the report to this file (.json for JSON, text otherwise)")
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fatalln("Usage: scenario [-target address] [-report file] <scenario.yaml>...")
+	}
+
+	var scenarios []scenario.Scenario
+	for _, path := range fs.Args() {
+		s, err := scenario.LoadFile(path)
+		if err != nil {
+			fatalln("Failed to load scenarios: ", err)
+		}
+
+		scenarios = append(scenarios, s...)
+	}
+
+	providers := interceptor.NewMetadataProviders(cfg.Metadata, version)
+	authOptions := authDialOptions(cfg.Auth)
+
+	runner := scenario.NewRunner(func(opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+		// the metadata is attached, the metrics counted and the spans started once per call,
+		// outside of the scenario patterns
+		opts = append(append([]grpc.DialOption{
+			grpc.WithChainUnaryInterceptor(interceptor.MetadataUnaryClientInterceptor(providers...)),
+			grpc.WithChainStreamInterceptor(interceptor.MetadataStreamClientInterceptor(providers...)),
+		}, instrumentationDialOptions()...), opts...)
+
+		opts = append(opts, authOptions...)
+		opts = append(opts, transportDialOption(cfg.TLS))
+		opts = append(opts, connectionDialOptions(cfg.Connection)...)
+
+		dialTarget, balancingOptions := balancingDialOptions(cfg.Balancing, *target)
+		opts = append(opts, balancingOptions...)
+
+		return grpc.NewClient(dialTarget, opts...)
+	}, clientMetrics)
+
+	report := runner.Run(context.Background(), scenarios)
+
+	if err := report.WriteText(os.Stdout); err != nil {
+		fatalln("Failed to write scenario report: ", err)
+	}
+
+	if *reportPath != "" {
+		if err := writeScenarioReport(report, *reportPath); err != nil {
+			fatalln("Failed to write scenario report: ", err)
+		}
+	}
+
+	if !report.Passed() {
+		exit(1)
+	}
+}
+
+func writeScenarioReport(report *scenario.Report, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if strings.HasSuffix(path, ".json") {
+		return report.WriteJSON(f)
+	}
+
+	return report.WriteText(f)
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"time"
+
+	"github.com/viquitorreis/my-grpc-go-client/internal/config"
+	"github.com/viquitorreis/my-grpc-go-client/internal/interceptor"
+	"github.com/viquitorreis/my-grpc-go-client/internal/tracing"
+	"google.golang.org/grpc"
+)
+
+// clientTracing is nil unless tracing exporters are configured
+var clientTracing *interceptor.Tracing
+
+// startTracing creates the tracer of cfg and flushes its spans at exit.
+func startTracing(cfg config.Tracing) {
+	if len(cfg.Exporters) == 0 {
+		return
+	}
+
+	var exporters []tracing.Exporter
+
+	for _, name := range cfg.Exporters {
+		switch name {
+		case "stdout":
+			exporters = append(exporters, tracing.NewJSONExporter(os.Stdout))
+		case "otlp":
+			exporters = append(exporters, tracing.NewOTLPExporter(tracing.OTLPSettings{
+				Endpoint: cfg.OTLP.Endpoint,
+				Headers:  cfg.OTLP.Headers,
+				Timeout:  cfg.OTLP.Timeout,
+			}))
+		}
+	}
+
+	tracer := tracing.NewTracer(tracing.TracerSettings{Service: cfg.Service}, exporters...)
+	clientTracing = interceptor.NewTracing(tracer)
+
+	atExit(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if err := tracer.Shutdown(ctx); err != nil {
+			log.Println("Failed to flush spans: ", err)
+		}
+	})
+}
+
+// instrumentationDialOptions adds the call timing handler and the metrics and tracing
+// interceptors, to be chained before any retry so that a call is counted and traced once.
+func instrumentationDialOptions() []grpc.DialOption {
+	unary := []grpc.UnaryClientInterceptor{clientMetrics.UnaryClientInterceptor()}
+	stream := []grpc.StreamClientInterceptor{clientMetrics.StreamClientInterceptor()}
+
+	if clientTracing != nil {
+		unary = append(unary, clientTracing.UnaryClientInterceptor())
+		stream = append(stream, clientTracing.StreamClientInterceptor())
+	}
+
+	return []grpc.DialOption{
+		grpc.WithStatsHandler(callTiming),
+		grpc.WithChainUnaryInterceptor(unary...),
+		grpc.WithChainStreamInterceptor(stream...),
+	}
+}
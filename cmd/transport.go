@@ -0,0 +1,34 @@
+package main
+
+import (
+	"github.com/viquitorreis/my-grpc-go-client/internal/config"
+	"github.com/viquitorreis/my-grpc-go-client/internal/tlsconfig"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// transportDialOption secures the connection with TLS when it is enabled in cfg.
+func transportDialOption(cfg config.TLS) grpc.DialOption {
+	if !cfg.Enabled {
+		return grpc.WithTransportCredentials(insecure.NewCredentials())
+	}
+
+	minVersion, err := tlsconfig.ParseVersion(cfg.MinVersion)
+	if err != nil {
+		fatalln("Failed to configure TLS: ", err)
+	}
+
+	reloader, err := tlsconfig.New(tlsconfig.Settings{
+		CAFile:     cfg.CAFile,
+		CertFile:   cfg.CertFile,
+		KeyFile:    cfg.KeyFile,
+		ServerName: cfg.ServerName,
+		MinVersion: minVersion,
+		URISANs:    cfg.ServerURISANs,
+	})
+	if err != nil {
+		fatalln("Failed to load TLS certificates: ", err)
+	}
+
+	return grpc.WithTransportCredentials(reloader.Credentials())
+}
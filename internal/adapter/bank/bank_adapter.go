@@ -6,8 +6,10 @@ import (
 	"io"
 	"log"
 
+	"github.com/viquitorreis/my-grpc-go-client/internal/adapter/callmeta"
 	domainBank "github.com/viquitorreis/my-grpc-go-client/internal/application/domain/bank"
 	"github.com/viquitorreis/my-grpc-go-client/internal/port"
+	"github.com/viquitorreis/my-grpc-go-client/internal/redact"
 	protoBank "github.com/viquitorreis/my-grpc-proto/protogen/go/bank"
 	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
@@ -29,21 +31,22 @@ func NewBankAdapter(conn *grpc.ClientConn) (*BankAdapter, error) {
 	}, nil
 }
 
-func (a *BankAdapter) GetCurrentBalance(ctx context.Context, account string) (*protoBank.CurrentBalanceResponse, error) {
+func (a *BankAdapter) GetCurrentBalance(ctx context.Context, account string, opts ...grpc.CallOption) (*protoBank.CurrentBalanceResponse, callmeta.Metadata, error) {
 	bankrequest := &protoBank.CurrentBalanceRequest{
 		AccountNumber: account,
 	}
 
-	bal, err := a.bankClient.GetCurrentBalance(ctx, bankrequest)
+	var md callmeta.Metadata
+	bal, err := a.bankClient.GetCurrentBalance(ctx, bankrequest, append(opts[:len(opts):len(opts)], md.CallOptions()...)...)
 	if err != nil {
 		st, _ := status.FromError(err)
 		log.Fatalln("[FATAL] failed to get current balance: ", st)
 	}
 
-	return bal, nil
+	return bal, md, nil
 }
 
-func (a *BankAdapter) FetchExchangeRates(ctx context.Context, fromCur, toCur string) {
+func (a *BankAdapter) FetchExchangeRates(ctx context.Context, fromCur, toCur string, opts ...grpc.CallOption) callmeta.Metadata {
 	if a.bankClient == nil {
 		log.Fatalln("[FATAL] bankClient is nil")
 	}
@@ -53,7 +56,7 @@ func (a *BankAdapter) FetchExchangeRates(ctx context.Context, fromCur, toCur str
 		ToCurrency:   toCur,
 	}
 
-	exchangeRateStream, err := a.bankClient.FetchExchangeRates(ctx, bankReq)
+	exchangeRateStream, err := a.bankClient.FetchExchangeRates(ctx, bankReq, opts...)
 	if err != nil {
 		st, _ := status.FromError(err)
 		log.Fatalln("[FATAL] failed to fetch exchange rates: ", st)
@@ -72,13 +75,18 @@ func (a *BankAdapter) FetchExchangeRates(ctx context.Context, fromCur, toCur str
 			if st.Code() == codes.InvalidArgument {
 				log.Fatalln("[FATAL] invalid argument: ", st)
 			}
+
+			log.Println("[ERROR] failed to receive exchange rate: ", st)
+			break
 		}
 
-		log.Printf("[INFO] time: %f exchange rate: from %s to %s\n", rate.Rate, rate.FromCurrency, rate.ToCurrency)
+		log.Println("[INFO] exchange rate:", redact.Message(rate))
 	}
+
+	return callmeta.FromStream(exchangeRateStream)
 }
 
-func (a *BankAdapter) SummarizeTransactions(ctx context.Context, account string, tx []*domainBank.Transaction) {
+func (a *BankAdapter) SummarizeTransactions(ctx context.Context, account string, tx []*domainBank.Transaction) callmeta.Metadata {
 	txStream, err := a.bankClient.SummarizeTransactions(ctx)
 	if err != nil {
 		st, _ := status.FromError(err)
@@ -113,10 +121,12 @@ func (a *BankAdapter) SummarizeTransactions(ctx context.Context, account string,
 		log.Fatalln("[FATAL] failed to get transaction summary: ", st)
 	}
 
-	log.Println(summary)
+	log.Println(redact.Message(summary))
+
+	return callmeta.FromStream(txStream)
 }
 
-func (a *BankAdapter) TransferMultiple(ctx context.Context, trf []domainBank.TransferTransaction) {
+func (a *BankAdapter) TransferMultiple(ctx context.Context, trf []domainBank.TransferTransaction) callmeta.Metadata {
 	trfStream, err := a.bankClient.TransferMultiple(ctx)
 	if err != nil {
 		st, _ := status.FromError(err)
@@ -141,10 +151,13 @@ func (a *BankAdapter) TransferMultiple(ctx context.Context, trf []domainBank.Tra
 		}
 
 		// depois que todas requisições forem enviadas, precisamos fechar a stream
+		trfStream.CloseSend()
 	}()
 
 	// 2ª goroutine vai receber mensagens do server usando o método Recv
 	go func() {
+		defer close(trfChan)
+
 		for {
 			resp, err := trfStream.Recv()
 			if err == io.EOF {
@@ -156,14 +169,14 @@ func (a *BankAdapter) TransferMultiple(ctx context.Context, trf []domainBank.Tra
 				handleTransferErrorGrpc(err)
 				break
 			} else {
-				log.Printf("[INFO] transfer satatus: %v time: %v\n", resp.Status, resp.Timestamp)
+				log.Println("[INFO] transfer status:", redact.Message(resp))
 			}
-
-			close(trfChan)
 		}
 	}()
 
 	<-trfChan
+
+	return callmeta.FromStream(trfStream)
 }
 
 func handleTransferErrorGrpc(err error) {
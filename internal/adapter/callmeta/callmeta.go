@@ -0,0 +1,48 @@
+package callmeta
+
+import (
+	"log"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Metadata holds the response headers and trailers captured from a call.
+type Metadata struct {
+	Header  metadata.MD
+	Trailer metadata.MD
+}
+
+// CallOptions fill m when passed to a unary call.
+func (m *Metadata) CallOptions() []grpc.CallOption {
+	return []grpc.CallOption{grpc.Header(&m.Header), grpc.Trailer(&m.Trailer)}
+}
+
+// FromStream captures the metadata of a stream. It must be called after the stream
+// ended (RecvMsg returned an error or io.EOF), otherwise the trailer is still empty.
+func FromStream(s grpc.ClientStream) Metadata {
+	// Header does not block once the stream has ended
+	header, _ := s.Header()
+
+	return Metadata{
+		Header:  header,
+		Trailer: s.Trailer(),
+	}
+}
+
+func (m Metadata) Log() {
+	logMD("Response header", m.Header)
+	logMD("Response trailer", m.Trailer)
+}
+
+func logMD(name string, md metadata.MD) {
+	if md.Len() == 0 {
+		log.Println(name, "not found")
+		return
+	}
+
+	log.Println(name + ": ")
+	for k, v := range md {
+		log.Printf(" %v: %v\n", k, v)
+	}
+}
@@ -6,7 +6,9 @@ import (
 	"log"
 	"time"
 
+	"github.com/viquitorreis/my-grpc-go-client/internal/adapter/callmeta"
 	"github.com/viquitorreis/my-grpc-go-client/internal/port"
+	"github.com/viquitorreis/my-grpc-go-client/internal/redact"
 	"github.com/viquitorreis/my-grpc-proto/protogen/go/hello"
 	"google.golang.org/grpc"
 )
@@ -21,21 +23,23 @@ func NewHelloAdapter(conn *grpc.ClientConn) (*HelloAdapter, error) {
 	}, nil
 }
 
-func (a *HelloAdapter) SayHello(ctx context.Context, name string) (*hello.HelloResponse, error) {
+func (a *HelloAdapter) SayHello(ctx context.Context, name string) (*hello.HelloResponse, callmeta.Metadata, error) {
 	helloRequest := &hello.HelloRequest{
 		Name: name,
 	}
 
-	greet, err := a.helloClient.SayHello(ctx, helloRequest)
+	var md callmeta.Metadata
+	greet, err := a.helloClient.SayHello(ctx, helloRequest, md.CallOptions()...)
 	if err != nil {
 		log.Fatal("Erro ao chamar o serviço de hello, err:", err)
 	}
 
 	// greet = hello response
-	return greet, nil
+	return greet, md, nil
 }
 
-func (a *HelloAdapter) SayManyHello(ctx context.Context, name string) (*hello.HelloResponse, error) {
+// SayManyHello returns the last greeting received from the server.
+func (a *HelloAdapter) SayManyHello(ctx context.Context, name string) (*hello.HelloResponse, callmeta.Metadata, error) {
 	helloRequest := &hello.HelloRequest{
 		Name: name,
 	}
@@ -45,13 +49,11 @@ func (a *HelloAdapter) SayManyHello(ctx context.Context, name string) (*hello.He
 		log.Fatalln("Erro ao chamar o serviço de hello, err:", err)
 	}
 
+	var last *hello.HelloResponse
+
 	// loop infinito para receber as mensagens do servidor
 	for {
 		greet, err := greetStream.Recv()
-		if err != nil {
-			log.Fatalln("Erro ao receber a mensagem do servidor, err:", err)
-		}
-
 		if err == io.EOF {
 			break
 		}
@@ -60,13 +62,14 @@ func (a *HelloAdapter) SayManyHello(ctx context.Context, name string) (*hello.He
 			log.Fatalln("Erro ao receber a mensagem do servidor, err:", err)
 		}
 
-		log.Println("Mensagem do servidor:", greet.Message)
+		log.Println("Mensagem do servidor:", redact.Message(greet))
+		last = greet
 	}
 
-	return nil, nil
+	return last, callmeta.FromStream(greetStream), nil
 }
 
-func (a *HelloAdapter) SayHelloToEveryone(ctx context.Context, names []string) {
+func (a *HelloAdapter) SayHelloToEveryone(ctx context.Context, names []string) callmeta.Metadata {
 	greetStream, err := a.helloClient.SayHelloToEveryone(ctx)
 	if err != nil {
 		log.Fatalln("Erro ao chamar o serviço SdayHelloToEveryone, err:", err)
@@ -88,10 +91,12 @@ func (a *HelloAdapter) SayHelloToEveryone(ctx context.Context, names []string) {
 		log.Fatalln("Erro ao fechar o stream, err:", err)
 	}
 
-	log.Println("Mensagem do servidor:", res.Message)
+	log.Println("Mensagem do servidor:", redact.Message(res))
+
+	return callmeta.FromStream(greetStream)
 }
 
-func (a *HelloAdapter) SayHelloContinuous(ctx context.Context, names []string) {
+func (a *HelloAdapter) SayHelloContinuous(ctx context.Context, names []string) callmeta.Metadata {
 	greetStream, err := a.helloClient.SayHelloContinuous(ctx)
 	if err != nil {
 		log.Fatalln("Erro ao chamar o serviço SayHelloContinuous, err:", err)
@@ -125,11 +130,13 @@ func (a *HelloAdapter) SayHelloContinuous(ctx context.Context, names []string) {
 				log.Fatalln("Erro ao receber a mensagem do servidor, err:", err)
 			}
 
-			log.Println("Mensagem do servidor:", res.Message)
+			log.Println("Mensagem do servidor:", redact.Message(res))
 		}
 		close(greetChan)
 	}()
 
 	// aguardando o fechamento do canal
 	<-greetChan
+
+	return callmeta.FromStream(greetStream)
 }
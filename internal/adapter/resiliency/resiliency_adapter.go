@@ -5,6 +5,7 @@ import (
 	"io"
 	"log"
 
+	"github.com/viquitorreis/my-grpc-go-client/internal/adapter/callmeta"
 	"github.com/viquitorreis/my-grpc-go-client/internal/port"
 	"github.com/viquitorreis/my-grpc-proto/protogen/go/resiliency"
 	"google.golang.org/grpc"
@@ -22,22 +23,23 @@ func NewResiliencyAdapter(conn *grpc.ClientConn) (*ResiliencyAdapter, error) {
 	}, nil
 }
 
-func (a *ResiliencyAdapter) UnaryResiliency(ctx context.Context, minDelaySecond int32, maxDelaySecond int32, statusCodes []uint32) (*resiliency.ResiliencyReponse, error) {
+func (a *ResiliencyAdapter) UnaryResiliency(ctx context.Context, minDelaySecond int32, maxDelaySecond int32, statusCodes []uint32) (*resiliency.ResiliencyReponse, callmeta.Metadata, error) {
 	resiliencyRequest := &resiliency.ResiliencyRequest{
 		MinDelaySecond: minDelaySecond,
 		MaxDelaySecond: maxDelaySecond,
 		StatusCodes:    statusCodes,
 	}
 
-	res, err := a.resiliencyClientPort.UnaryResiliency(ctx, resiliencyRequest)
+	var md callmeta.Metadata
+	res, err := a.resiliencyClientPort.UnaryResiliency(ctx, resiliencyRequest, md.CallOptions()...)
 	if err != nil {
-		return nil, err
+		return nil, md, err
 	}
 
-	return res, err
+	return res, md, err
 }
 
-func (a *ResiliencyAdapter) ServerStreamingResiliency(ctx context.Context, minDelaySecond int32, maxDelaySecond int32, statusCodes []uint32) {
+func (a *ResiliencyAdapter) ServerStreamingResiliency(ctx context.Context, minDelaySecond int32, maxDelaySecond int32, statusCodes []uint32) callmeta.Metadata {
 	resiliencyRequest := &resiliency.ResiliencyRequest{
 		MinDelaySecond: minDelaySecond,
 		MaxDelaySecond: maxDelaySecond,
@@ -61,9 +63,11 @@ func (a *ResiliencyAdapter) ServerStreamingResiliency(ctx context.Context, minDe
 
 		log.Println("ServerStreamingResiliency: ", res.DummyString)
 	}
+
+	return callmeta.FromStream(resilStream)
 }
 
-func (a *ResiliencyAdapter) ClientStreamResiliency(ctx context.Context, minDelaySecond int32, maxDelaySecond int32, statusCodes []uint32, count int) {
+func (a *ResiliencyAdapter) ClientStreamResiliency(ctx context.Context, minDelaySecond int32, maxDelaySecond int32, statusCodes []uint32, count int) callmeta.Metadata {
 	resilStream, err := a.resiliencyClientPort.ClientStreamResiliency(ctx)
 	if err != nil {
 		log.Fatalln("Error on ClientStreamResiliency 1: ", err)
@@ -86,9 +90,11 @@ func (a *ResiliencyAdapter) ClientStreamResiliency(ctx context.Context, minDelay
 	}
 
 	log.Println("ClientStreamResiliency: ", res.DummyString)
+
+	return callmeta.FromStream(resilStream)
 }
 
-func (a *ResiliencyAdapter) BidirectionalStreamingResiliency(ctx context.Context, minDelaySecond int32, maxDelaySecond int32, statusCodes []uint32, count int) {
+func (a *ResiliencyAdapter) BidirectionalStreamingResiliency(ctx context.Context, minDelaySecond int32, maxDelaySecond int32, statusCodes []uint32, count int) callmeta.Metadata {
 	resilStream, err := a.resiliencyClientPort.BidirectionalStreamResiliency(ctx)
 	if err != nil {
 		log.Fatalln("Error on BidirectionalStreamingResiliency: ", err)
@@ -133,4 +139,6 @@ func (a *ResiliencyAdapter) BidirectionalStreamingResiliency(ctx context.Context
 
 	// bloqueando até receber um valor do go channel
 	<-resilChan
+
+	return callmeta.FromStream(resilStream)
 }
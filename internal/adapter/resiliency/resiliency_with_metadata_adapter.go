@@ -2,63 +2,35 @@ package resiliency
 
 import (
 	"context"
-	"fmt"
 	"io"
 	"log"
-	"runtime"
-	"time"
 
-	"github.com/google/uuid"
+	"github.com/viquitorreis/my-grpc-go-client/internal/adapter/callmeta"
 	"github.com/viquitorreis/my-grpc-proto/protogen/go/resiliency"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/metadata"
 )
 
-func sampleRequestMetadata() metadata.MD {
-	md := map[string]string{
-		"grpc-client-time":  fmt.Sprintf(time.Now().Format("15:04:05")),
-		"grpc-client-os":    runtime.GOOS,
-		"grpc-request-uuid": uuid.New().String(),
-	}
-
-	return metadata.New(md)
-}
-
-func sampleResponseMetadata(md metadata.MD) {
-	if md.Len() == 0 {
-		log.Println("Response metadata not found")
-	} else {
-		log.Println("Response metadata: ")
-		for k, v := range md {
-			log.Printf(" %v: %v\n", k, v)
-		}
-	}
-}
-
-func (a *ResiliencyAdapter) UnaryResiliencyWithMetadata(ctx context.Context, minDelaySecond int32, maxDelaySecond int32, statusCodes []uint32) (*resiliency.ResiliencyReponse, error) {
-	ctx = metadata.NewOutgoingContext(ctx, sampleRequestMetadata())
+// O request metadata é adicionado pelos metadata providers (interceptor) no início da chamada
 
+func (a *ResiliencyAdapter) UnaryResiliencyWithMetadata(ctx context.Context, minDelaySecond int32, maxDelaySecond int32, statusCodes []uint32) (*resiliency.ResiliencyReponse, callmeta.Metadata, error) {
 	resiliencyRequest := &resiliency.ResiliencyRequest{
 		MinDelaySecond: minDelaySecond,
 		MaxDelaySecond: maxDelaySecond,
 		StatusCodes:    statusCodes,
 	}
 
-	var responseMetadata metadata.MD
-	res, err := a.resiliencyClientWithMetadata.UnaryResiliencyWithMetadata(ctx, resiliencyRequest, grpc.Header(&responseMetadata))
+	var md callmeta.Metadata
+	res, err := a.resiliencyClientWithMetadata.UnaryResiliencyWithMetadata(ctx, resiliencyRequest, md.CallOptions()...)
 	if err != nil {
 		log.Println("Error on UnaryResiliencyWithMetadata: ", err)
-		return nil, err
+		return nil, md, err
 	}
 
-	sampleResponseMetadata(responseMetadata)
+	md.Log()
 
-	return res, err
+	return res, md, err
 }
 
-func (a *ResiliencyAdapter) ServerStreamingResiliencyWithMetadata(ctx context.Context, minDelaySecond int32, maxDelaySecond int32, statusCodes []uint32) {
-	ctx = metadata.NewOutgoingContext(ctx, sampleRequestMetadata())
-
+func (a *ResiliencyAdapter) ServerStreamingResiliencyWithMetadata(ctx context.Context, minDelaySecond int32, maxDelaySecond int32, statusCodes []uint32) callmeta.Metadata {
 	resiliencyRequest := &resiliency.ResiliencyRequest{
 		MinDelaySecond: minDelaySecond,
 		MaxDelaySecond: maxDelaySecond,
@@ -69,13 +41,6 @@ func (a *ResiliencyAdapter) ServerStreamingResiliencyWithMetadata(ctx context.Co
 	if err != nil {
 		log.Fatalln("Error on ServerStreamResiliencyWithMetadata: ", err)
 	}
-	if err != nil {
-		log.Fatalln("Error on ServerStreamingResiliencyWithMetadata: ", err)
-	}
-
-	if responseMetada, err := resilStream.Header(); err == nil {
-		sampleResponseMetadata(responseMetada)
-	}
 
 	for {
 		res, err := resilStream.Recv()
@@ -89,9 +54,14 @@ func (a *ResiliencyAdapter) ServerStreamingResiliencyWithMetadata(ctx context.Co
 
 		log.Println("ServerStreamResiliencyWithMetadata: ", res.DummyString)
 	}
+
+	md := callmeta.FromStream(resilStream)
+	md.Log()
+
+	return md
 }
 
-func (a *ResiliencyAdapter) ClientStreamResiliencyWithMetadata(ctx context.Context, minDelaySecond int32, maxDelaySecond int32, statusCodes []uint32, count int) {
+func (a *ResiliencyAdapter) ClientStreamResiliencyWithMetadata(ctx context.Context, minDelaySecond int32, maxDelaySecond int32, statusCodes []uint32, count int) callmeta.Metadata {
 	resilStream, err := a.resiliencyClientWithMetadata.ClientStreamResiliencyWithMetadata(ctx)
 	if err != nil {
 		log.Fatalln("Error on ClientStreamResiliencyWithMetadata 1: ", err)
@@ -99,8 +69,6 @@ func (a *ResiliencyAdapter) ClientStreamResiliencyWithMetadata(ctx context.Conte
 
 	for i := 0; i < count; i++ {
 		// streaming request para o servidor
-		ctx = metadata.NewOutgoingContext(ctx, sampleRequestMetadata())
-
 		test := &resiliency.ResiliencyRequest{
 			MinDelaySecond: minDelaySecond,
 			MaxDelaySecond: maxDelaySecond,
@@ -115,34 +83,26 @@ func (a *ResiliencyAdapter) ClientStreamResiliencyWithMetadata(ctx context.Conte
 		log.Fatalln("Error on ClientStreamResiliencyWithMetadata 2: ", err)
 	}
 
-	if responseMetadata, err := resilStream.Header(); err == nil {
-		sampleResponseMetadata(responseMetadata)
-	}
+	md := callmeta.FromStream(resilStream)
+	md.Log()
 
 	log.Println("ClientStreamResiliencyWithMetadata: ", res.DummyString)
+
+	return md
 }
 
-func (a *ResiliencyAdapter) BidirectionalStreamResiliencyWithMetadata(ctx context.Context, minDelaySecond int32, maxDelaySecond int32, statusCodes []uint32, count int) {
+func (a *ResiliencyAdapter) BidirectionalStreamResiliencyWithMetadata(ctx context.Context, minDelaySecond int32, maxDelaySecond int32, statusCodes []uint32, count int) callmeta.Metadata {
 	resilStream, err := a.resiliencyClientWithMetadata.BidirectionalStreamResiliencyWithMetadata(ctx)
 	if err != nil {
 		log.Fatalln("Error on BidirectionalStreamResiliencyWithMetadata: ", err)
 	}
 
-	ctx, cancel := context.WithCancel(ctx)
-	defer cancel()
-
-	if responseMetadata, err := resilStream.Header(); err == nil {
-		sampleResponseMetadata(responseMetadata)
-	}
-
 	resilChan := make(chan struct{})
 
 	// primeira goroutine vai enviar o número espec´ficiado de requisições resilientes para o servidor
 	go func() {
 		for i := 0; i < count; i++ {
 			// streaming request para o servidor
-			ctx = metadata.NewOutgoingContext(ctx, sampleRequestMetadata())
-
 			resiliencyRequest := &resiliency.ResiliencyRequest{
 				MinDelaySecond: minDelaySecond,
 				MaxDelaySecond: maxDelaySecond,
@@ -176,4 +136,9 @@ func (a *ResiliencyAdapter) BidirectionalStreamResiliencyWithMetadata(ctx contex
 
 	// bloqueando até receber um valor do go channel
 	<-resilChan
+
+	md := callmeta.FromStream(resilStream)
+	md.Log()
+
+	return md
 }
@@ -0,0 +1,124 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/credentials"
+)
+
+const authorizationHeader = "authorization"
+
+// tokenCredentials sends a fixed header value on every call.
+type tokenCredentials struct {
+	header     string
+	value      string
+	requireTLS bool
+}
+
+// Bearer sends token as an authorization bearer token.
+func Bearer(token string, requireTLS bool) credentials.PerRPCCredentials {
+	return &tokenCredentials{header: authorizationHeader, value: "Bearer " + token, requireTLS: requireTLS}
+}
+
+// APIKey sends key in header, e.g. x-api-key.
+func APIKey(header, key string, requireTLS bool) credentials.PerRPCCredentials {
+	return &tokenCredentials{header: strings.ToLower(header), value: key, requireTLS: requireTLS}
+}
+
+func (c *tokenCredentials) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	return map[string]string{c.header: c.value}, nil
+}
+
+func (c *tokenCredentials) RequireTransportSecurity() bool {
+	return c.requireTLS
+}
+
+// FileToken sends the bearer token stored in a file, read again whenever the file changes,
+// e.g. when a sidecar rotates it.
+type FileToken struct {
+	path       string
+	requireTLS bool
+
+	mu      sync.Mutex
+	token   string
+	modTime time.Time
+	size    int64
+}
+
+func NewFileToken(path string, requireTLS bool) (*FileToken, error) {
+	t := &FileToken{path: path, requireTLS: requireTLS}
+
+	if err := t.reload(); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func (t *FileToken) reload() error {
+	info, err := os.Stat(t.path)
+	if err != nil {
+		return err
+	}
+
+	if info.ModTime().Equal(t.modTime) && info.Size() == t.size {
+		return nil
+	}
+
+	b, err := os.ReadFile(t.path)
+	if err != nil {
+		return err
+	}
+
+	token := strings.TrimSpace(string(b))
+	if token == "" {
+		return fmt.Errorf("token file %v is empty", t.path)
+	}
+
+	t.token = token
+	t.modTime = info.ModTime()
+	t.size = info.Size()
+
+	return nil
+}
+
+func (t *FileToken) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// a token being rewritten is retried on the next call, the previous one is still good until then
+	if err := t.reload(); err != nil {
+		log.Println("Failed to reload token file, keeping the previous token: ", err)
+	}
+
+	return map[string]string{authorizationHeader: "Bearer " + t.token}, nil
+}
+
+func (t *FileToken) RequireTransportSecurity() bool {
+	return t.requireTLS
+}
+
+// ForMethods sends creds only on the calls whose method matches.
+func ForMethods(creds credentials.PerRPCCredentials, match func(method string) bool) credentials.PerRPCCredentials {
+	return &methodCredentials{PerRPCCredentials: creds, match: match}
+}
+
+type methodCredentials struct {
+	credentials.PerRPCCredentials
+	match func(method string) bool
+}
+
+func (c *methodCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	info, ok := credentials.RequestInfoFromContext(ctx)
+	if ok && !c.match(info.Method) {
+		return nil, nil
+	}
+
+	return c.PerRPCCredentials.GetRequestMetadata(ctx, uri...)
+}
@@ -0,0 +1,193 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type JWTSettings struct {
+	// Algorithm is HS256, signed with Secret, or RS256, signed with PrivateKey.
+	Algorithm  string
+	Secret     []byte
+	PrivateKey *rsa.PrivateKey
+	// KeyID is sent as the kid header when set.
+	KeyID    string
+	Issuer   string
+	Subject  string
+	Audience string
+	// Claims are added to the registered ones, and may override them.
+	Claims map[string]any
+	// TTL is how long a token is valid, 5 minutes by default.
+	TTL time.Duration
+	// RefreshBefore is how long before its expiry a token is replaced, a fifth of TTL by default.
+	RefreshBefore time.Duration
+	RequireTLS    bool
+}
+
+// JWT sends a bearer token signed locally, signing a new one shortly before the current one expires.
+type JWT struct {
+	settings JWTSettings
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+func NewJWT(settings JWTSettings) (*JWT, error) {
+	switch settings.Algorithm {
+	case "HS256":
+		if len(settings.Secret) == 0 {
+			return nil, errors.New("jwt HS256 needs a secret")
+		}
+	case "RS256":
+		if settings.PrivateKey == nil {
+			return nil, errors.New("jwt RS256 needs a private key")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported jwt algorithm %q, expected HS256 or RS256", settings.Algorithm)
+	}
+
+	if settings.TTL <= 0 {
+		settings.TTL = 5 * time.Minute
+	}
+
+	if settings.RefreshBefore <= 0 || settings.RefreshBefore >= settings.TTL {
+		settings.RefreshBefore = settings.TTL / 5
+	}
+
+	j := &JWT{settings: settings}
+
+	// signing once up front reports a bad key at startup rather than on the first call
+	if _, err := j.Token(); err != nil {
+		return nil, err
+	}
+
+	return j, nil
+}
+
+// Token returns the current token, signing a new one when it is about to expire.
+func (j *JWT) Token() (string, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	now := time.Now()
+	if j.token != "" && now.Before(j.expires.Add(-j.settings.RefreshBefore)) {
+		return j.token, nil
+	}
+
+	expires := now.Add(j.settings.TTL)
+
+	token, err := j.sign(now, expires)
+	if err != nil {
+		return "", err
+	}
+
+	j.token = token
+	j.expires = expires
+
+	return token, nil
+}
+
+func (j *JWT) sign(now, expires time.Time) (string, error) {
+	header := map[string]any{"alg": j.settings.Algorithm, "typ": "JWT"}
+	if j.settings.KeyID != "" {
+		header["kid"] = j.settings.KeyID
+	}
+
+	claims := map[string]any{
+		"iat": now.Unix(),
+		"nbf": now.Unix(),
+		"exp": expires.Unix(),
+		"jti": uuid.New().String(),
+	}
+
+	for k, v := range map[string]string{"iss": j.settings.Issuer, "sub": j.settings.Subject, "aud": j.settings.Audience} {
+		if v != "" {
+			claims[k] = v
+		}
+	}
+
+	maps.Copy(claims, j.settings.Claims)
+
+	h, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+
+	c, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("jwt claims: %w", err)
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(c)
+
+	var signature []byte
+
+	switch j.settings.Algorithm {
+	case "HS256":
+		mac := hmac.New(sha256.New, j.settings.Secret)
+		mac.Write([]byte(signingInput))
+		signature = mac.Sum(nil)
+	case "RS256":
+		digest := sha256.Sum256([]byte(signingInput))
+
+		signature, err = rsa.SignPKCS1v15(rand.Reader, j.settings.PrivateKey, crypto.SHA256, digest[:])
+		if err != nil {
+			return "", fmt.Errorf("jwt signature: %w", err)
+		}
+	}
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+func (j *JWT) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	token, err := j.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]string{authorizationHeader: "Bearer " + token}, nil
+}
+
+func (j *JWT) RequireTransportSecurity() bool {
+	return j.settings.RequireTLS
+}
+
+// ParseRSAPrivateKey reads a PEM encoded PKCS#1 or PKCS#8 RSA private key.
+func ParseRSAPrivateKey(pemBytes []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, errors.New("no PEM block in private key")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse private key: %w", err)
+	}
+
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("private key is not an RSA key")
+	}
+
+	return rsaKey, nil
+}
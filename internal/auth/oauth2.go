@@ -0,0 +1,290 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type OAuth2Settings struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	// Params are extra form parameters of the token request, e.g. audience.
+	Params map[string]string
+	// SecretInBody sends the client credentials as form parameters instead of HTTP basic auth.
+	SecretInBody bool
+	// RefreshBefore is how long before its expiry a token is replaced, 1 minute by default.
+	RefreshBefore time.Duration
+	// DefaultExpiry applies to tokens answered without expires_in, 5 minutes by default.
+	DefaultExpiry time.Duration
+	// Retries and Backoff control how failed token requests are retried, with exponential backoff.
+	Retries int
+	Backoff time.Duration
+	// Timeout bounds each token request, 10 seconds by default.
+	Timeout    time.Duration
+	RequireTLS bool
+}
+
+// OAuth2 sends access tokens obtained with the client credentials grant. Tokens are cached and
+// refreshed in the background shortly before they expire, and concurrent calls share a single
+// token request.
+type OAuth2 struct {
+	settings OAuth2Settings
+	client   *http.Client
+
+	mu        sync.Mutex
+	token     string
+	expires   time.Time
+	refreshAt time.Time
+	inflight  chan struct{}
+	err       error
+}
+
+func NewOAuth2(settings OAuth2Settings) *OAuth2 {
+	if settings.RefreshBefore <= 0 {
+		settings.RefreshBefore = time.Minute
+	}
+
+	if settings.DefaultExpiry <= 0 {
+		settings.DefaultExpiry = 5 * time.Minute
+	}
+
+	if settings.Retries < 0 {
+		settings.Retries = 0
+	}
+
+	if settings.Backoff <= 0 {
+		settings.Backoff = 500 * time.Millisecond
+	}
+
+	if settings.Timeout <= 0 {
+		settings.Timeout = 10 * time.Second
+	}
+
+	return &OAuth2{
+		settings: settings,
+		client:   &http.Client{Timeout: settings.Timeout},
+	}
+}
+
+// Token returns a valid access token, requesting one when there is none or it expired.
+func (o *OAuth2) Token(ctx context.Context) (string, error) {
+	o.mu.Lock()
+
+	now := time.Now()
+
+	if o.token != "" && now.Before(o.expires) {
+		token := o.token
+
+		// still valid: refresh in the background and keep using it meanwhile
+		if !now.Before(o.refreshAt) && o.inflight == nil {
+			o.startRefresh()
+		}
+
+		o.mu.Unlock()
+
+		return token, nil
+	}
+
+	if o.inflight == nil {
+		o.startRefresh()
+	}
+
+	inflight := o.inflight
+	o.mu.Unlock()
+
+	select {
+	case <-inflight:
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.token == "" || !time.Now().Before(o.expires) {
+		return "", fmt.Errorf("oauth2 token: %w", o.err)
+	}
+
+	return o.token, nil
+}
+
+// startRefresh requests a token in the background, o.mu must be held.
+func (o *OAuth2) startRefresh() {
+	inflight := make(chan struct{})
+	o.inflight = inflight
+
+	go func() {
+		requested := time.Now()
+		token, expires, err := o.fetchWithRetries()
+
+		o.mu.Lock()
+		if err == nil {
+			o.token, o.expires = token, expires
+			// short-lived tokens are refreshed halfway through their life at the latest
+			o.refreshAt = expires.Add(-min(o.settings.RefreshBefore, expires.Sub(requested)/2))
+		} else {
+			log.Println("Failed to refresh oauth2 token: ", err)
+		}
+
+		o.err = err
+		o.inflight = nil
+		o.mu.Unlock()
+
+		close(inflight)
+	}()
+}
+
+func (o *OAuth2) fetchWithRetries() (string, time.Time, error) {
+	var err error
+
+	for attempt := 0; attempt <= o.settings.Retries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(o.settings.Backoff * time.Duration(1<<(attempt-1)))
+		}
+
+		var (
+			token   string
+			expires time.Time
+		)
+
+		token, expires, err = o.fetch()
+		if err == nil {
+			return token, expires, nil
+		}
+
+		var tokenErr *TokenError
+		if errors.As(err, &tokenErr) && !tokenErr.retryable() {
+			break
+		}
+	}
+
+	return "", time.Time{}, err
+}
+
+// TokenError is an error answer of the token endpoint.
+type TokenError struct {
+	StatusCode  int
+	Code        string
+	Description string
+}
+
+func (e *TokenError) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("token endpoint answered %v", e.StatusCode)
+	}
+
+	return fmt.Sprintf("token endpoint answered %v: %v %v", e.StatusCode, e.Code, e.Description)
+}
+
+// retryable is false for rejected credentials or requests, which fail the same way again.
+func (e *TokenError) retryable() bool {
+	return e.StatusCode >= 500 || e.StatusCode == http.StatusTooManyRequests
+}
+
+func (o *OAuth2) fetch() (string, time.Time, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(o.settings.Scopes) > 0 {
+		form.Set("scope", strings.Join(o.settings.Scopes, " "))
+	}
+
+	for k, v := range o.settings.Params {
+		form.Set(k, v)
+	}
+
+	if o.settings.SecretInBody {
+		form.Set("client_id", o.settings.ClientID)
+		form.Set("client_secret", o.settings.ClientSecret)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, o.settings.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	if !o.settings.SecretInBody {
+		// RFC 6749 section 2.3.1 form-encodes the credentials before basic auth
+		req.SetBasicAuth(url.QueryEscape(o.settings.ClientID), url.QueryEscape(o.settings.ClientSecret))
+	}
+
+	requested := time.Now()
+
+	res, err := o.client.Do(req)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	var answer struct {
+		AccessToken      string `json:"access_token"`
+		TokenType        string `json:"token_type"`
+		ExpiresIn        int64  `json:"expires_in"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+
+	jsonErr := json.Unmarshal(body, &answer)
+
+	if res.StatusCode/100 != 2 {
+		return "", time.Time{}, &TokenError{StatusCode: res.StatusCode, Code: answer.Error, Description: answer.ErrorDescription}
+	}
+
+	if jsonErr != nil {
+		return "", time.Time{}, fmt.Errorf("invalid token answer: %w", jsonErr)
+	}
+
+	if answer.AccessToken == "" {
+		return "", time.Time{}, errors.New("token answer without access_token")
+	}
+
+	if answer.TokenType != "" && !strings.EqualFold(answer.TokenType, "bearer") {
+		return "", time.Time{}, fmt.Errorf("unsupported token type %q", answer.TokenType)
+	}
+
+	expiry := o.settings.DefaultExpiry
+	if answer.ExpiresIn > 0 {
+		expiry = time.Duration(answer.ExpiresIn) * time.Second
+	}
+
+	// the expiry counts from when the token was requested, to be on the safe side
+	return answer.AccessToken, requested.Add(expiry), nil
+}
+
+func (o *OAuth2) GetRequestMetadata(ctx context.Context, _ ...string) (map[string]string, error) {
+	token, err := o.Token(ctx)
+	if err != nil {
+		var tokenErr *TokenError
+		if errors.As(err, &tokenErr) && !tokenErr.retryable() {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+
+	return map[string]string{authorizationHeader: "Bearer " + token}, nil
+}
+
+func (o *OAuth2) RequireTransportSecurity() bool {
+	return o.settings.RequireTLS
+}
@@ -0,0 +1,255 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// tokenServer answers token requests with answer, given the 1-based number of the request.
+type tokenServer struct {
+	*httptest.Server
+	requests atomic.Int64
+}
+
+func newTokenServer(t *testing.T, answer func(n int64, w http.ResponseWriter, r *http.Request)) *tokenServer {
+	t.Helper()
+
+	s := &tokenServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		answer(s.requests.Add(1), w, r)
+	}))
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func writeToken(w http.ResponseWriter, token string, expiresIn int) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"access_token": token,
+		"token_type":   "Bearer",
+		"expires_in":   expiresIn,
+	})
+}
+
+func writeError(w http.ResponseWriter, code int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]any{
+		"error":             "some_error",
+		"error_description": http.StatusText(code),
+	})
+}
+
+func authorization(t *testing.T, o *OAuth2) string {
+	t.Helper()
+
+	md, err := o.GetRequestMetadata(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return md[authorizationHeader]
+}
+
+func TestOAuth2CachesToken(t *testing.T) {
+	s := newTokenServer(t, func(n int64, w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "client_credentials" {
+			writeError(w, http.StatusBadRequest)
+			return
+		}
+
+		if id, secret, ok := r.BasicAuth(); !ok || id != "client" || secret != "secret" {
+			writeError(w, http.StatusUnauthorized)
+			return
+		}
+
+		writeToken(w, fmt.Sprintf("token-%d", n), 3600)
+	})
+
+	o := NewOAuth2(OAuth2Settings{TokenURL: s.URL, ClientID: "client", ClientSecret: "secret"})
+
+	for range 3 {
+		if got := authorization(t, o); got != "Bearer token-1" {
+			t.Fatalf("authorization = %q, want Bearer token-1", got)
+		}
+	}
+
+	if n := s.requests.Load(); n != 1 {
+		t.Fatalf("%d token requests, want 1", n)
+	}
+}
+
+func TestOAuth2ConcurrentCallsShareOneRequest(t *testing.T) {
+	release := make(chan struct{})
+
+	s := newTokenServer(t, func(n int64, w http.ResponseWriter, r *http.Request) {
+		<-release
+		writeToken(w, fmt.Sprintf("token-%d", n), 3600)
+	})
+
+	o := NewOAuth2(OAuth2Settings{TokenURL: s.URL})
+
+	const calls = 20
+
+	var wg sync.WaitGroup
+	errs := make(chan error, calls)
+
+	for range calls {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			md, err := o.GetRequestMetadata(context.Background())
+			if err == nil && md[authorizationHeader] != "Bearer token-1" {
+				err = fmt.Errorf("authorization = %q, want Bearer token-1", md[authorizationHeader])
+			}
+
+			errs <- err
+		}()
+	}
+
+	// let the calls pile up on the pending request
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if n := s.requests.Load(); n != 1 {
+		t.Fatalf("%d token requests for %d concurrent calls, want 1", n, calls)
+	}
+}
+
+func TestOAuth2RefreshesBeforeExpiry(t *testing.T) {
+	s := newTokenServer(t, func(n int64, w http.ResponseWriter, r *http.Request) {
+		writeToken(w, fmt.Sprintf("token-%d", n), 2)
+	})
+
+	o := NewOAuth2(OAuth2Settings{TokenURL: s.URL, RefreshBefore: time.Second})
+
+	if got := authorization(t, o); got != "Bearer token-1" {
+		t.Fatalf("authorization = %q, want Bearer token-1", got)
+	}
+
+	// past the refresh time but before the expiry, the cached token is still sent
+	time.Sleep(1100 * time.Millisecond)
+
+	if got := authorization(t, o); got != "Bearer token-1" {
+		t.Fatalf("authorization = %q, want Bearer token-1 while refreshing", got)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for authorization(t, o) != "Bearer token-2" {
+		if time.Now().After(deadline) {
+			t.Fatal("the token was not refreshed before it expired")
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if n := s.requests.Load(); n != 2 {
+		t.Fatalf("%d token requests, want 2", n)
+	}
+}
+
+func TestOAuth2RetriesWithBackoff(t *testing.T) {
+	failures := []int{http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusInternalServerError}
+
+	s := newTokenServer(t, func(n int64, w http.ResponseWriter, r *http.Request) {
+		if int(n) <= len(failures) {
+			writeError(w, failures[n-1])
+			return
+		}
+
+		writeToken(w, "token", 3600)
+	})
+
+	o := NewOAuth2(OAuth2Settings{TokenURL: s.URL, Retries: 3, Backoff: 20 * time.Millisecond})
+
+	start := time.Now()
+
+	if got := authorization(t, o); got != "Bearer token" {
+		t.Fatalf("authorization = %q, want Bearer token", got)
+	}
+
+	if n := s.requests.Load(); n != 4 {
+		t.Fatalf("%d token requests, want 4", n)
+	}
+
+	// 20ms, 40ms and 80ms between the attempts
+	if elapsed := time.Since(start); elapsed < 140*time.Millisecond {
+		t.Fatalf("retried within %v, want at least 140ms of backoff", elapsed)
+	}
+}
+
+func TestOAuth2DoesNotRetryRejections(t *testing.T) {
+	for _, code := range []int{http.StatusBadRequest, http.StatusUnauthorized} {
+		t.Run(http.StatusText(code), func(t *testing.T) {
+			s := newTokenServer(t, func(n int64, w http.ResponseWriter, r *http.Request) {
+				writeError(w, code)
+			})
+
+			o := NewOAuth2(OAuth2Settings{TokenURL: s.URL, Retries: 3, Backoff: time.Millisecond})
+
+			if _, err := o.GetRequestMetadata(context.Background()); err == nil {
+				t.Fatal("expected an error")
+			}
+
+			if n := s.requests.Load(); n != 1 {
+				t.Fatalf("%d token requests, want 1", n)
+			}
+		})
+	}
+}
+
+func TestOAuth2ErrorCodes(t *testing.T) {
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	tests := []struct {
+		name     string
+		tokenURL func(t *testing.T) string
+		want     codes.Code
+	}{
+		{"bad request", answering(http.StatusBadRequest), codes.Unauthenticated},
+		{"unauthorized", answering(http.StatusUnauthorized), codes.Unauthenticated},
+		{"server error", answering(http.StatusInternalServerError), codes.Unavailable},
+		{"too many requests", answering(http.StatusTooManyRequests), codes.Unavailable},
+		{"unreachable", func(*testing.T) string { return closed.URL }, codes.Unavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := NewOAuth2(OAuth2Settings{TokenURL: tt.tokenURL(t), Backoff: time.Millisecond})
+
+			_, err := o.GetRequestMetadata(context.Background())
+			if got := status.Code(err); got != tt.want {
+				t.Fatalf("code = %v (%v), want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func answering(code int) func(t *testing.T) string {
+	return func(t *testing.T) string {
+		return newTokenServer(t, func(n int64, w http.ResponseWriter, r *http.Request) {
+			writeError(w, code)
+		}).URL
+	}
+}
@@ -0,0 +1,86 @@
+package balancing
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"google.golang.org/grpc/balancer/roundrobin"
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/resolver/manual"
+
+	// registers the client side of grpc.health.v1, used by the healthCheckConfig of ServiceConfig
+	_ "google.golang.org/grpc/health"
+)
+
+// The policies of the balancing config.
+const (
+	PolicyPickFirst          = "pick_first"
+	PolicyRoundRobin         = "round_robin"
+	PolicyWeightedRoundRobin = "weighted_round_robin"
+)
+
+type Settings struct {
+	Policy string
+	// HealthCheck watches the grpc.health.v1 status of HealthService on every endpoint and
+	// takes the endpoints not SERVING out of rotation. pick_first ignores it.
+	HealthCheck   bool
+	HealthService string
+}
+
+// ServiceConfig returns the JSON service config selecting the policy of settings.
+func ServiceConfig(settings Settings) (string, error) {
+	var name string
+
+	switch settings.Policy {
+	case "", PolicyPickFirst:
+		name = PolicyPickFirst
+	case PolicyRoundRobin:
+		name = roundrobin.Name
+	case PolicyWeightedRoundRobin:
+		name = WeightedRoundRobin
+	default:
+		return "", fmt.Errorf("unknown balancing policy %q", settings.Policy)
+	}
+
+	sc := map[string]any{
+		"loadBalancingConfig": []map[string]any{{name: map[string]any{}}},
+	}
+
+	if settings.HealthCheck {
+		sc["healthCheckConfig"] = map[string]any{"serviceName": settings.HealthService}
+	}
+
+	b, err := json.Marshal(sc)
+
+	return string(b), err
+}
+
+// Endpoint is a replica of the service.
+type Endpoint struct {
+	Address string
+	// Weight is used by weighted_round_robin, 0 counts as 1.
+	Weight uint32
+}
+
+// Addresses converts endpoints to the addresses of a resolver.State.
+func Addresses(endpoints []Endpoint) []resolver.Address {
+	addrs := make([]resolver.Address, 0, len(endpoints))
+	for _, e := range endpoints {
+		addr := resolver.Address{Addr: e.Address}
+		if e.Weight > 0 {
+			addr = SetWeight(addr, e.Weight)
+		}
+
+		addrs = append(addrs, addr)
+	}
+
+	return addrs
+}
+
+// StaticResolver resolves the targets of scheme to endpoints, e.g. endpoints:///bank.
+func StaticResolver(scheme string, endpoints []Endpoint) resolver.Builder {
+	r := manual.NewBuilderWithScheme(scheme)
+	r.InitialState(resolver.State{Addresses: Addresses(endpoints)})
+
+	return r
+}
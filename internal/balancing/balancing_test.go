@@ -0,0 +1,266 @@
+package balancing
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/resolver/manual"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// replica is a server counting the calls and the connections it got.
+type replica struct {
+	addr   string
+	health *health.Server
+	calls  atomic.Int64
+	conns  atomic.Int64
+}
+
+type countingListener struct {
+	net.Listener
+	conns *atomic.Int64
+}
+
+func (l countingListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err == nil {
+		l.conns.Add(1)
+	}
+
+	return conn, err
+}
+
+func startReplicas(t *testing.T, n int) []*replica {
+	t.Helper()
+
+	replicas := make([]*replica, n)
+
+	for i := range replicas {
+		r := &replica{health: health.NewServer()}
+
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		r.addr = lis.Addr().String()
+
+		srv := grpc.NewServer(grpc.UnknownServiceHandler(func(_ any, stream grpc.ServerStream) error {
+			r.calls.Add(1)
+
+			var msg durationpb.Duration
+			if err := stream.RecvMsg(&msg); err != nil {
+				return err
+			}
+
+			return stream.SendMsg(&msg)
+		}))
+		healthpb.RegisterHealthServer(srv, r.health)
+
+		go srv.Serve(countingListener{Listener: lis, conns: &r.conns})
+		t.Cleanup(srv.Stop)
+
+		replicas[i] = r
+	}
+
+	return replicas
+}
+
+func dial(t *testing.T, settings Settings, addrs []resolver.Address) (*grpc.ClientConn, *manual.Resolver) {
+	t.Helper()
+
+	sc, err := ServiceConfig(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := manual.NewBuilderWithScheme("test")
+	r.InitialState(resolver.State{Addresses: addrs})
+
+	conn, err := grpc.NewClient("test:///bank",
+		grpc.WithResolvers(r),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultServiceConfig(sc),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return conn, r
+}
+
+func endpoints(replicas []*replica, weights ...uint32) []Endpoint {
+	endpoints := make([]Endpoint, len(replicas))
+	for i, r := range replicas {
+		endpoints[i] = Endpoint{Address: r.addr}
+		if i < len(weights) {
+			endpoints[i].Weight = weights[i]
+		}
+	}
+
+	return endpoints
+}
+
+func invoke(t *testing.T, conn *grpc.ClientConn) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := conn.Invoke(ctx, "/test.Echo/Unary", durationpb.New(time.Second), &durationpb.Duration{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// spread makes n calls and returns how many each replica got.
+func spread(t *testing.T, conn *grpc.ClientConn, replicas []*replica, n int) []int64 {
+	t.Helper()
+
+	for _, r := range replicas {
+		r.calls.Store(0)
+	}
+
+	for range n {
+		invoke(t, conn)
+	}
+
+	counts := make([]int64, len(replicas))
+	for i, r := range replicas {
+		counts[i] = r.calls.Load()
+	}
+
+	return counts
+}
+
+// eventually calls spread until ok accepts the counts.
+func eventually(t *testing.T, conn *grpc.ClientConn, replicas []*replica, n int, ok func([]int64) bool) []int64 {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+
+	for {
+		counts := spread(t, conn, replicas, n)
+		if ok(counts) {
+			return counts
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("unexpected spread of the calls: %v", counts)
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// allServed waits until every replica gets calls.
+func allServed(t *testing.T, conn *grpc.ClientConn, replicas []*replica) {
+	t.Helper()
+
+	eventually(t, conn, replicas, 10*len(replicas), func(counts []int64) bool {
+		for _, c := range counts {
+			if c == 0 {
+				return false
+			}
+		}
+
+		return true
+	})
+}
+
+func within(got, want int64) bool {
+	return got >= want-1 && got <= want+1
+}
+
+func TestRoundRobin(t *testing.T) {
+	replicas := startReplicas(t, 3)
+	conn, _ := dial(t, Settings{Policy: PolicyRoundRobin}, Addresses(endpoints(replicas)))
+
+	allServed(t, conn, replicas)
+
+	counts := spread(t, conn, replicas, 30)
+	for i, c := range counts {
+		if c != 10 {
+			t.Fatalf("replica %d got %d calls out of 30, want 10: %v", i, c, counts)
+		}
+	}
+}
+
+func TestWeightedRoundRobin(t *testing.T) {
+	replicas := startReplicas(t, 3)
+	conn, _ := dial(t, Settings{Policy: PolicyWeightedRoundRobin}, Addresses(endpoints(replicas, 5, 3, 0)))
+
+	allServed(t, conn, replicas)
+
+	// the weight 0 counts as 1
+	counts := spread(t, conn, replicas, 90)
+	for i, want := range []int64{50, 30, 10} {
+		if !within(counts[i], want) {
+			t.Fatalf("replica %d got %d calls out of 90, want %d: %v", i, counts[i], want, counts)
+		}
+	}
+}
+
+func TestNotServingLeavesRotation(t *testing.T) {
+	for _, policy := range []string{PolicyRoundRobin, PolicyWeightedRoundRobin} {
+		t.Run(policy, func(t *testing.T) {
+			replicas := startReplicas(t, 2)
+			conn, _ := dial(t, Settings{Policy: policy, HealthCheck: true}, Addresses(endpoints(replicas)))
+
+			allServed(t, conn, replicas)
+
+			replicas[1].health.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+
+			eventually(t, conn, replicas, 20, func(counts []int64) bool {
+				return counts[1] == 0
+			})
+
+			replicas[1].health.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+
+			eventually(t, conn, replicas, 20, func(counts []int64) bool {
+				return counts[1] > 0
+			})
+
+			if counts := spread(t, conn, replicas, 20); !within(counts[0], 10) || !within(counts[1], 10) {
+				t.Fatalf("unexpected spread once back: %v", counts)
+			}
+		})
+	}
+}
+
+func TestWeightUpdateKeepsConnections(t *testing.T) {
+	replicas := startReplicas(t, 2)
+	conn, r := dial(t, Settings{Policy: PolicyWeightedRoundRobin}, Addresses(endpoints(replicas, 3, 1)))
+
+	allServed(t, conn, replicas)
+
+	counts := spread(t, conn, replicas, 40)
+	if !within(counts[0], 30) || !within(counts[1], 10) {
+		t.Fatalf("unexpected spread before the update: %v", counts)
+	}
+
+	conns := make([]int64, len(replicas))
+	for i, rep := range replicas {
+		conns[i] = rep.conns.Load()
+	}
+
+	r.UpdateState(resolver.State{Addresses: Addresses(endpoints(replicas, 1, 3))})
+
+	eventually(t, conn, replicas, 40, func(counts []int64) bool {
+		return within(counts[0], 10) && within(counts[1], 30)
+	})
+
+	for i, rep := range replicas {
+		if got := rep.conns.Load(); got != conns[i] {
+			t.Fatalf("replica %d was reconnected, %d connections before the update, %d after", i, conns[i], got)
+		}
+	}
+}
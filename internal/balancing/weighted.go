@@ -0,0 +1,128 @@
+package balancing
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+	"google.golang.org/grpc/resolver"
+)
+
+// WeightedRoundRobin is the name of the balancer spreading the calls over the ready
+// endpoints in proportion to the weights of their addresses, set with SetWeight. Unlike the
+// weighted_round_robin of grpc, the weights are static and no load report is needed.
+const WeightedRoundRobin = "static_weighted_round_robin"
+
+func init() {
+	balancer.Register(weightedBuilder{})
+}
+
+type weightKey struct{}
+
+// SetWeight sets the weight of addr for WeightedRoundRobin, addresses default to 1.
+func SetWeight(addr resolver.Address, weight uint32) resolver.Address {
+	addr.BalancerAttributes = addr.BalancerAttributes.WithValue(weightKey{}, weight)
+	return addr
+}
+
+// Weight returns the weight of addr, 1 when none was set.
+func Weight(addr resolver.Address) uint32 {
+	if w, ok := addr.BalancerAttributes.Value(weightKey{}).(uint32); ok && w > 0 {
+		return w
+	}
+
+	return 1
+}
+
+type weightedBuilder struct{}
+
+func (weightedBuilder) Name() string {
+	return WeightedRoundRobin
+}
+
+func (weightedBuilder) Build(cc balancer.ClientConn, opts balancer.BuildOptions) balancer.Balancer {
+	b := &weightedBalancer{}
+	b.weights.Store(&map[string]uint32{})
+
+	// the base balancer keeps the addresses the subconns were created with, so the weights
+	// are looked up in the last resolver update instead, to change them without reconnecting
+	b.Balancer = base.NewBalancerBuilder(WeightedRoundRobin, &weightedPickerBuilder{weights: &b.weights},
+		base.Config{HealthCheck: true}).Build(cc, opts)
+
+	return b
+}
+
+type weightedBalancer struct {
+	balancer.Balancer
+
+	weights atomic.Pointer[map[string]uint32]
+}
+
+func (b *weightedBalancer) UpdateClientConnState(s balancer.ClientConnState) error {
+	weights := make(map[string]uint32, len(s.ResolverState.Addresses))
+	for _, addr := range s.ResolverState.Addresses {
+		weights[addr.Addr] = Weight(addr)
+	}
+
+	b.weights.Store(&weights)
+
+	return b.Balancer.UpdateClientConnState(s)
+}
+
+type weightedPickerBuilder struct {
+	weights *atomic.Pointer[map[string]uint32]
+}
+
+func (pb *weightedPickerBuilder) Build(info base.PickerBuildInfo) balancer.Picker {
+	if len(info.ReadySCs) == 0 {
+		return base.NewErrPicker(balancer.ErrNoSubConnAvailable)
+	}
+
+	weights := *pb.weights.Load()
+
+	p := &weightedPicker{}
+	for sc, sci := range info.ReadySCs {
+		w, ok := weights[sci.Address.Addr]
+		if !ok {
+			w = Weight(sci.Address)
+		}
+
+		p.items = append(p.items, &weightedItem{sc: sc, weight: int64(w)})
+		p.total += int64(w)
+	}
+
+	return p
+}
+
+// weightedPicker is a smooth weighted round robin: every pick raises each item by its
+// weight and picks the highest, which is then lowered by the total. Weights 3 and 1 pick
+// a, a, b, a rather than a, a, a, b.
+type weightedPicker struct {
+	mu    sync.Mutex
+	items []*weightedItem
+	total int64
+}
+
+type weightedItem struct {
+	sc      balancer.SubConn
+	weight  int64
+	current int64
+}
+
+func (p *weightedPicker) Pick(balancer.PickInfo) (balancer.PickResult, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var best *weightedItem
+	for _, item := range p.items {
+		item.current += item.weight
+		if best == nil || item.current > best.current {
+			best = item
+		}
+	}
+
+	best.current -= p.total
+
+	return balancer.PickResult{SubConn: best.sc}, nil
+}
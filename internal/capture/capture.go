@@ -0,0 +1,86 @@
+package capture
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/metadata"
+)
+
+type Direction string
+
+const (
+	// DirectionStart opens a call and carries its request metadata.
+	DirectionStart Direction = "start"
+	DirectionSend  Direction = "send"
+	DirectionRecv  Direction = "recv"
+	// DirectionEnd closes a call with its status, header and trailer.
+	DirectionEnd Direction = "end"
+)
+
+type Status struct {
+	Code    string `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+// Entry is one line of a capture file. The entries of a call share its CallID and
+// always go start, then send and recv in the order they happened, then end.
+type Entry struct {
+	CallID    string    `json:"call_id"`
+	Method    string    `json:"method"`
+	Type      string    `json:"type"`
+	Direction Direction `json:"direction"`
+	// Seq is the index of the message within its direction.
+	Seq  int             `json:"seq,omitempty"`
+	Time time.Time       `json:"time"`
+	Body json.RawMessage `json:"body,omitempty"`
+	// Redacted are the full names of the fields masked in Body, it is not the message as sent
+	// or received when set. Masked numbers and bools may be zeroed and then missing from Body.
+	Redacted []string    `json:"redacted,omitempty"`
+	Metadata metadata.MD `json:"metadata,omitempty"`
+	Header   metadata.MD `json:"header,omitempty"`
+	Trailer  metadata.MD `json:"trailer,omitempty"`
+	Status   *Status     `json:"status,omitempty"`
+}
+
+// Writer appends entries to a JSONL capture, it is safe for concurrent use.
+type Writer struct {
+	mu     sync.Mutex
+	enc    *json.Encoder
+	closer io.Closer
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{enc: json.NewEncoder(w)}
+}
+
+// Create opens the capture file at path for appending, creating it if needed.
+func Create(path string) (*Writer, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	w := NewWriter(f)
+	w.closer = f
+
+	return w, nil
+}
+
+func (w *Writer) Write(e *Entry) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.enc.Encode(e)
+}
+
+func (w *Writer) Close() error {
+	if w.closer == nil {
+		return nil
+	}
+
+	return w.closer.Close()
+}
@@ -0,0 +1,112 @@
+package capture
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"time"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// Call is a recorded call rebuilt from its entries.
+type Call struct {
+	ID       string
+	Method   string
+	Type     string
+	Start    time.Time
+	End      time.Time
+	Metadata metadata.MD
+	Sent     []json.RawMessage
+	Received []json.RawMessage
+	// SentRedacted and ReceivedRedacted are the fields masked in Sent and Received, see Entry.Redacted.
+	SentRedacted     []string
+	ReceivedRedacted []string
+	Header           metadata.MD
+	Trailer          metadata.MD
+	// Status is nil when the capture stopped before the call ended.
+	Status *Status
+}
+
+// ReadFile reads the calls of a capture file, in the order they started.
+func ReadFile(path string) ([]*Call, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	calls, err := Read(f)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", path, err)
+	}
+
+	return calls, nil
+}
+
+func Read(r io.Reader) ([]*Call, error) {
+	var (
+		calls []*Call
+		byID  = make(map[string]*Call)
+	)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		c, ok := byID[e.CallID]
+		if !ok {
+			c = &Call{ID: e.CallID, Method: e.Method, Type: e.Type, Start: e.Time}
+			byID[e.CallID] = c
+			calls = append(calls, c)
+		}
+
+		switch e.Direction {
+		case DirectionStart:
+			c.Start = e.Time
+			c.Metadata = e.Metadata
+		case DirectionSend:
+			c.Sent = append(c.Sent, e.Body)
+			c.SentRedacted = appendNew(c.SentRedacted, e.Redacted)
+		case DirectionRecv:
+			c.Received = append(c.Received, e.Body)
+			c.ReceivedRedacted = appendNew(c.ReceivedRedacted, e.Redacted)
+		case DirectionEnd:
+			c.End = e.Time
+			c.Header = e.Header
+			c.Trailer = e.Trailer
+			c.Status = e.Status
+		default:
+			return nil, fmt.Errorf("line %d: unknown direction %q", line, e.Direction)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return calls, nil
+}
+
+// appendNew appends the names missing from names.
+func appendNew(names, more []string) []string {
+	for _, name := range more {
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
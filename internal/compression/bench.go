@@ -0,0 +1,160 @@
+package compression
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"google.golang.org/grpc/encoding"
+)
+
+// Sample is a serialized message to benchmark, e.g. one read from a capture.
+type Sample struct {
+	Method string
+	// Direction is send or recv.
+	Direction string
+	Payload   []byte
+}
+
+// Result is the outcome of a codec on the samples of one method and direction.
+type Result struct {
+	Method    string `json:"method"`
+	Direction string `json:"direction"`
+	Codec     string `json:"codec"`
+	Messages  int    `json:"messages"`
+	// Compressed is how many messages reached the threshold, the others are counted as is.
+	Compressed      int           `json:"compressed"`
+	Bytes           int           `json:"bytes"`
+	CompressedBytes int           `json:"compressed_bytes"`
+	CompressTime    time.Duration `json:"compress_ns"`
+	DecompressTime  time.Duration `json:"decompress_ns"`
+}
+
+// Ratio is the size after compression over the size before, lower is better.
+func (r *Result) Ratio() float64 {
+	if r.Bytes == 0 {
+		return 1
+	}
+
+	return float64(r.CompressedBytes) / float64(r.Bytes)
+}
+
+// Benchmark compresses and decompresses the samples at least minSize long with each codec,
+// rounds times, and reports the sizes and the time spent per round.
+func Benchmark(samples []Sample, codecs []string, minSize, rounds int) ([]*Result, error) {
+	rounds = max(rounds, 1)
+
+	var results []*Result
+	byKey := make(map[[3]string]*Result)
+
+	for _, name := range codecs {
+		c := encoding.GetCompressor(name)
+		if c == nil {
+			return nil, fmt.Errorf("unknown codec %q", name)
+		}
+
+		for _, s := range samples {
+			key := [3]string{s.Method, s.Direction, name}
+
+			r, ok := byKey[key]
+			if !ok {
+				r = &Result{Method: s.Method, Direction: s.Direction, Codec: name}
+				byKey[key] = r
+				results = append(results, r)
+			}
+
+			r.Messages++
+			r.Bytes += len(s.Payload)
+
+			if len(s.Payload) < minSize {
+				r.CompressedBytes += len(s.Payload)
+				continue
+			}
+
+			compressed, compressTime, err := measure(rounds, func() ([]byte, error) { return compress(c, s.Payload) })
+			if err != nil {
+				return nil, fmt.Errorf("%v with %v: %w", s.Method, name, err)
+			}
+
+			_, decompressTime, err := measure(rounds, func() ([]byte, error) { return decompress(c, compressed) })
+			if err != nil {
+				return nil, fmt.Errorf("%v with %v: %w", s.Method, name, err)
+			}
+
+			r.Compressed++
+			r.CompressedBytes += len(compressed)
+			r.CompressTime += compressTime
+			r.DecompressTime += decompressTime
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Method != results[j].Method {
+			return results[i].Method < results[j].Method
+		}
+
+		return results[i].Direction < results[j].Direction
+	})
+
+	return results, nil
+}
+
+func measure(rounds int, f func() ([]byte, error)) ([]byte, time.Duration, error) {
+	var out []byte
+
+	start := time.Now()
+	for range rounds {
+		var err error
+		if out, err = f(); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return out, time.Since(start) / time.Duration(rounds), nil
+}
+
+func compress(c encoding.Compressor, p []byte) ([]byte, error) {
+	var buf bytes.Buffer
+
+	w, err := c.Compress(&buf)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := w.Write(p); err != nil {
+		return nil, err
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func decompress(c encoding.Compressor, p []byte) ([]byte, error) {
+	r, err := c.Decompress(bytes.NewReader(p))
+	if err != nil {
+		return nil, err
+	}
+
+	return io.ReadAll(r)
+}
+
+// WriteText writes the results as a table.
+func WriteText(w io.Writer, results []*Result) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+
+	fmt.Fprintln(tw, "METHOD\tDIRECTION\tCODEC\tMESSAGES\tCOMPRESSED\tBYTES\tAFTER\tRATIO\tCOMPRESS\tDECOMPRESS\t")
+
+	for _, r := range results {
+		fmt.Fprintf(tw, "%v\t%v\t%v\t%d\t%d\t%d\t%d\t%.3f\t%v\t%v\t\n",
+			r.Method, r.Direction, r.Codec, r.Messages, r.Compressed, r.Bytes, r.CompressedBytes,
+			r.Ratio(), r.CompressTime, r.DecompressTime)
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,40 @@
+package compression
+
+import (
+	"slices"
+	"sync"
+
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/encoding/gzip"
+)
+
+var (
+	mu    sync.Mutex
+	names = []string{gzip.Name}
+)
+
+// Register makes the codec c available to calls and to Benchmark under c.Name(). Like
+// encoding.RegisterCompressor, it must be called before the connections are created.
+func Register(c encoding.Compressor) {
+	encoding.RegisterCompressor(c)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if !slices.Contains(names, c.Name()) {
+		names = append(names, c.Name())
+	}
+}
+
+// Registered reports whether a codec is registered under name.
+func Registered(name string) bool {
+	return encoding.GetCompressor(name) != nil
+}
+
+// Names returns the registered codecs, gzip first.
+func Names() []string {
+	mu.Lock()
+	defer mu.Unlock()
+
+	return slices.Clone(names)
+}
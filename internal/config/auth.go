@@ -0,0 +1,98 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// Auth configures the credentials sent with every call.
+type Auth struct {
+	// Type is bearer, bearer_file, api_key, jwt or oauth2. Empty sends no credentials.
+	Type string `yaml:"type"`
+	// Methods limits the credentials to some methods, with the patterns of the interceptors section.
+	Methods []string `yaml:"methods"`
+	// RequireTLS refuses to send the credentials over connections without transport security.
+	RequireTLS bool `yaml:"require_tls"`
+	// Token is the bearer token, TokenFile a file holding it that is read again when it changes.
+	Token     string `yaml:"token"`
+	TokenFile string `yaml:"token_file"`
+	// Header and APIKey are the api_key header, x-api-key by default, and its value.
+	Header string `yaml:"header"`
+	APIKey string `yaml:"api_key"`
+	JWT    JWT    `yaml:"jwt"`
+	OAuth2 OAuth2 `yaml:"oauth2"`
+}
+
+type JWT struct {
+	// Algorithm is HS256, signed with Secret or SecretFile, or RS256, signed with PrivateKeyFile.
+	Algorithm      string         `yaml:"algorithm"`
+	Secret         string         `yaml:"secret"`
+	SecretFile     string         `yaml:"secret_file"`
+	PrivateKeyFile string         `yaml:"private_key_file"`
+	KeyID          string         `yaml:"key_id"`
+	Issuer         string         `yaml:"issuer"`
+	Subject        string         `yaml:"subject"`
+	Audience       string         `yaml:"audience"`
+	Claims         map[string]any `yaml:"claims"`
+	TTL            time.Duration  `yaml:"ttl"`
+	RefreshBefore  time.Duration  `yaml:"refresh_before"`
+}
+
+// OAuth2 configures the client credentials grant.
+type OAuth2 struct {
+	TokenURL         string            `yaml:"token_url"`
+	ClientID         string            `yaml:"client_id"`
+	ClientSecret     string            `yaml:"client_secret"`
+	ClientSecretFile string            `yaml:"client_secret_file"`
+	Scopes           []string          `yaml:"scopes"`
+	Params           map[string]string `yaml:"params"`
+	// SecretInBody sends the client credentials as form parameters instead of HTTP basic auth.
+	SecretInBody  bool          `yaml:"secret_in_body"`
+	RefreshBefore time.Duration `yaml:"refresh_before"`
+	Retries       int           `yaml:"retries"`
+	Backoff       time.Duration `yaml:"backoff"`
+	Timeout       time.Duration `yaml:"timeout"`
+}
+
+func (a Auth) validate() error {
+	switch a.Type {
+	case "":
+	case "bearer":
+		if a.Token == "" {
+			return fmt.Errorf("auth bearer needs a token")
+		}
+	case "bearer_file":
+		if a.TokenFile == "" {
+			return fmt.Errorf("auth bearer_file needs a token_file")
+		}
+	case "api_key":
+		if a.APIKey == "" {
+			return fmt.Errorf("auth api_key needs an api_key")
+		}
+	case "jwt":
+		switch a.JWT.Algorithm {
+		case "HS256":
+			if a.JWT.Secret == "" && a.JWT.SecretFile == "" {
+				return fmt.Errorf("auth jwt HS256 needs a secret or secret_file")
+			}
+		case "RS256":
+			if a.JWT.PrivateKeyFile == "" {
+				return fmt.Errorf("auth jwt RS256 needs a private_key_file")
+			}
+		default:
+			return fmt.Errorf("unknown auth jwt algorithm %q", a.JWT.Algorithm)
+		}
+	case "oauth2":
+		if a.OAuth2.TokenURL == "" || a.OAuth2.ClientID == "" {
+			return fmt.Errorf("auth oauth2 needs a token_url and a client_id")
+		}
+
+		if a.OAuth2.ClientSecret == "" && a.OAuth2.ClientSecretFile == "" {
+			return fmt.Errorf("auth oauth2 needs a client_secret or client_secret_file")
+		}
+	default:
+		return fmt.Errorf("unknown auth type %q", a.Type)
+	}
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Balancing spreads the calls over several replicas of Target.
+type Balancing struct {
+	// Endpoints are the addresses of the replicas, Target then only names the service, e.g.
+	// for the TLS server name. Without endpoints Target is resolved as usual, e.g.
+	// dns:///bank.internal:9090, or file:///etc/bank-endpoints.json to watch an endpoints file.
+	Endpoints []Endpoint `yaml:"endpoints"`
+	// Policy is pick_first, round_robin or weighted_round_robin, which uses the endpoint weights.
+	Policy      string      `yaml:"policy"`
+	HealthCheck HealthCheck `yaml:"health_check"`
+}
+
+// Endpoint is written as host:port, or as a mapping to set its weight.
+type Endpoint struct {
+	Address string `yaml:"address"`
+	Weight  uint32 `yaml:"weight"`
+}
+
+func (e *Endpoint) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		return value.Decode(&e.Address)
+	}
+
+	type endpoint Endpoint
+
+	return value.Decode((*endpoint)(e))
+}
+
+// HealthCheck takes the endpoints that are not SERVING in grpc.health.v1 out of rotation.
+type HealthCheck struct {
+	Enabled bool `yaml:"enabled"`
+	// Service is the service name checked, empty for the whole server.
+	Service string `yaml:"service"`
+}
+
+func (b Balancing) validate() error {
+	switch b.Policy {
+	case "", "pick_first", "round_robin", "weighted_round_robin":
+	default:
+		return fmt.Errorf("unknown balancing policy %q, expected pick_first, round_robin or weighted_round_robin", b.Policy)
+	}
+
+	for i, e := range b.Endpoints {
+		if e.Address == "" {
+			return fmt.Errorf("balancing endpoint %d has no address", i)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,28 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Capture records the calls of the client to a JSONL file.
+type Capture struct {
+	// Path of the capture file, nothing is recorded when empty.
+	Path string `yaml:"path"`
+	// Methods are full method names or path.Match patterns, patterns starting with ! exclude
+	// methods. Every method is recorded when empty.
+	Methods []string `yaml:"methods"`
+	// MaskMetadata are the metadata keys whose values are never written.
+	MaskMetadata []string `yaml:"mask_metadata"`
+}
+
+func (c Capture) validate() error {
+	for _, m := range c.Methods {
+		if _, err := path.Match(strings.TrimPrefix(m, "!"), ""); err != nil {
+			return fmt.Errorf("invalid capture method pattern %q: %w", m, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,180 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+type Profile string
+
+const (
+	ProfileDevelopment Profile = "development"
+	ProfileProduction  Profile = "production"
+)
+
+// Config is the client configuration, read from a YAML file passed with -config.
+type Config struct {
+	// Profile defaults to production so that development-only features stay off unless asked for.
+	Profile        Profile        `yaml:"profile"`
+	Target         string         `yaml:"target"`
+	TLS            TLS            `yaml:"tls"`
+	Balancing      Balancing      `yaml:"balancing"`
+	Connection     Connection     `yaml:"connection"`
+	Auth           Auth           `yaml:"auth"`
+	Logging        Logging        `yaml:"logging"`
+	Redaction      Redaction      `yaml:"redaction"`
+	Metadata       Metadata       `yaml:"metadata"`
+	Capture        Capture        `yaml:"capture"`
+	Replay         Replay         `yaml:"replay"`
+	Mock           Mock           `yaml:"mock"`
+	Metrics        Metrics        `yaml:"metrics"`
+	Tracing        Tracing        `yaml:"tracing"`
+	FaultInjection FaultInjection `yaml:"fault_injection"`
+	// Interceptors replaces the whole default chain when set.
+	Interceptors []Interceptor `yaml:"interceptors"`
+}
+
+func Default() *Config {
+	return &Config{
+		Profile: ProfileProduction,
+		Target:  "localhost:9090",
+		Connection: Connection{
+			Keepalive: Keepalive{
+				Timeout: 20 * time.Second,
+			},
+		},
+		Auth: Auth{
+			Header: "x-api-key",
+			JWT: JWT{
+				TTL: 5 * time.Minute,
+			},
+			OAuth2: OAuth2{
+				RefreshBefore: time.Minute,
+				Retries:       3,
+				Backoff:       500 * time.Millisecond,
+				Timeout:       10 * time.Second,
+			},
+		},
+		Logging: Logging{
+			Format: "text",
+			Level:  slog.LevelInfo,
+		},
+		Redaction: Redaction{
+			Fields: []string{
+				"*account_number",
+				"*.account_name",
+				"*amount",
+				"*.sum_*",
+				"*.name",
+			},
+			Numbers: "zero",
+		},
+		Metadata: Metadata{
+			RequestID:     true,
+			ClientVersion: true,
+			Host:          true,
+			Propagate: Propagation{
+				Keys: []string{"x-request-id", "x-tenant-id"},
+			},
+		},
+		Capture: Capture{
+			MaskMetadata: []string{"authorization", "x-api-key"},
+		},
+		Replay: Replay{
+			Ignore: []string{"*.timestamp", "*.dummy_string"},
+		},
+		Mock: Mock{
+			Listen: "localhost:9090",
+			Match:  "body",
+		},
+		Tracing: Tracing{
+			Service: "my-grpc-go-client",
+			OTLP: OTLP{
+				Endpoint: "http://localhost:4318/v1/traces",
+				Timeout:  10 * time.Second,
+			},
+		},
+		Interceptors: DefaultInterceptors(),
+	}
+}
+
+// Load reads the config file at path on top of the defaults. An empty path returns the defaults.
+func Load(path string) (*Config, error) {
+	cfg := Default()
+	if path == "" {
+		return cfg, nil
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(b, cfg); err != nil {
+		return nil, fmt.Errorf("%v: %w", path, err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("%v: %w", path, err)
+	}
+
+	return cfg, nil
+}
+
+func (c *Config) Validate() error {
+	switch c.Profile {
+	case ProfileDevelopment, ProfileProduction:
+	default:
+		return fmt.Errorf("unknown profile %q", c.Profile)
+	}
+
+	if err := c.TLS.validate(); err != nil {
+		return err
+	}
+
+	if err := c.Balancing.validate(); err != nil {
+		return err
+	}
+
+	if err := c.Connection.validate(); err != nil {
+		return err
+	}
+
+	if err := c.Auth.validate(); err != nil {
+		return err
+	}
+
+	if err := c.Logging.validate(); err != nil {
+		return err
+	}
+
+	if err := c.Redaction.validate(); err != nil {
+		return err
+	}
+
+	if err := c.Capture.validate(); err != nil {
+		return err
+	}
+
+	if err := c.Mock.validate(); err != nil {
+		return err
+	}
+
+	if err := c.Tracing.validate(); err != nil {
+		return err
+	}
+
+	if err := validateInterceptors(c.Interceptors); err != nil {
+		return err
+	}
+
+	if c.FaultInjection.Enabled && c.Profile == ProfileProduction {
+		return fmt.Errorf("fault_injection can not be enabled with the %v profile", c.Profile)
+	}
+
+	return nil
+}
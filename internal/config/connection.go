@@ -0,0 +1,38 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// Connection configures the lifecycle of the connections to Target.
+type Connection struct {
+	Keepalive Keepalive `yaml:"keepalive"`
+	// IdleTimeout is how long a connection without calls stays open, 30m when zero.
+	IdleTimeout time.Duration `yaml:"idle_timeout"`
+	// ReadyTimeout waits for the connection to be ready before the first call, and fails
+	// after it. Zero lets the calls connect on their own.
+	ReadyTimeout time.Duration `yaml:"ready_timeout"`
+}
+
+// Keepalive pings the server over idle transports to detect broken connections early.
+type Keepalive struct {
+	// Time is how long a transport stays quiet before a ping, zero disables keepalive.
+	Time time.Duration `yaml:"time"`
+	// Timeout is how long to wait for the ping ack before closing the transport.
+	Timeout time.Duration `yaml:"timeout"`
+	// PermitWithoutStream also pings transports without calls.
+	PermitWithoutStream bool `yaml:"permit_without_stream"`
+}
+
+func (c Connection) validate() error {
+	if c.Keepalive.Time != 0 && c.Keepalive.Time < 10*time.Second {
+		return fmt.Errorf("connection keepalive time %v is below the 10s minimum of grpc", c.Keepalive.Time)
+	}
+
+	if c.Keepalive.Timeout < 0 || c.IdleTimeout < 0 || c.ReadyTimeout < 0 {
+		return fmt.Errorf("connection timeouts can not be negative")
+	}
+
+	return nil
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"time"
+)
+
+// FaultInjection configures client-side chaos testing. It is refused by the production profile.
+type FaultInjection struct {
+	Enabled bool `yaml:"enabled"`
+	// Seed makes the injected faults reproducible, 0 picks a random seed.
+	Seed uint64 `yaml:"seed"`
+	// Methods is keyed by full method name, "*" applies to methods without their own entry.
+	Methods map[string]MethodFaults `yaml:"methods"`
+}
+
+type MethodFaults struct {
+	Delay       *DelayFault `yaml:"delay"`
+	AbortBefore *AbortFault `yaml:"abort_before"`
+	AbortAfter  *AbortFault `yaml:"abort_after"`
+	// DropMessage is the probability of silently discarding a received stream message.
+	DropMessage float64 `yaml:"drop_message"`
+	// CorruptMessage is the probability of garbling one field of a received stream message.
+	CorruptMessage float64 `yaml:"corrupt_message"`
+	// CutAfterMessages ends the stream with CutCode once that many messages were received.
+	CutAfterMessages int    `yaml:"cut_after_messages"`
+	CutCode          string `yaml:"cut_code"`
+}
+
+type DelayFault struct {
+	Probability float64       `yaml:"probability"`
+	Min         time.Duration `yaml:"min"`
+	Max         time.Duration `yaml:"max"`
+}
+
+type AbortFault struct {
+	Probability float64 `yaml:"probability"`
+	// Code is the gRPC code name, e.g. UNAVAILABLE.
+	Code    string `yaml:"code"`
+	Message string `yaml:"message"`
+}
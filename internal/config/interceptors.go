@@ -0,0 +1,81 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Interceptor is an entry of the interceptor chain, in the order the calls go through them.
+type Interceptor struct {
+	// Name is the name the interceptor is registered under, e.g. retry.
+	Name string `yaml:"name"`
+	// Methods are full method name patterns for path.Match, e.g. /bank.BankService/*. Patterns
+	// starting with ! exclude methods. Without patterns the interceptor applies to every method.
+	Methods []string `yaml:"methods"`
+	// Options are decoded by the interceptor, see its registration.
+	Options yaml.Node `yaml:"options"`
+}
+
+// DecodeOptions decodes the options into v, leaving v alone when there are none.
+func (i Interceptor) DecodeOptions(v any) error {
+	if i.Options.Kind == 0 {
+		return nil
+	}
+
+	if err := i.Options.Decode(v); err != nil {
+		return fmt.Errorf("interceptor %v options: %w", i.Name, err)
+	}
+
+	return nil
+}
+
+// DefaultInterceptors is the chain used when the config does not declare one.
+func DefaultInterceptors() []Interceptor {
+	names := []string{
+		"propagation",
+		"metadata",
+		"tracing",
+		"logging",
+		"metrics",
+		"fallback",
+		"concurrency_limit",
+		"transform",
+		"timeout",
+		"record",
+		"fault_injection",
+	}
+
+	chain := make([]Interceptor, 0, len(names)+1)
+	for _, name := range names {
+		chain = append(chain, Interceptor{Name: name})
+
+		// the breaker sits below fallback, which answers the bank reads while it is open
+		if name == "fallback" {
+			chain = append(chain, Interceptor{
+				Name:    "circuit_breaker",
+				Methods: []string{"/bank.BankService/GetCurrentBalance", "/bank.BankService/FetchExchangeRates"},
+			})
+		}
+	}
+
+	return chain
+}
+
+func validateInterceptors(chain []Interceptor) error {
+	for i, entry := range chain {
+		if entry.Name == "" {
+			return fmt.Errorf("interceptor %d has no name", i)
+		}
+
+		for _, pattern := range entry.Methods {
+			if _, err := path.Match(strings.TrimPrefix(pattern, "!"), ""); err != nil {
+				return fmt.Errorf("interceptor %v: invalid method pattern %q", entry.Name, pattern)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+type Logging struct {
+	// Format is text or json.
+	Format string `yaml:"format"`
+	// Level is the minimum level written, info by default.
+	Level slog.Level `yaml:"level"`
+	// Methods overrides the level of the call records of a method, keyed by full method name.
+	Methods map[string]slog.Level `yaml:"methods"`
+	// Sample keeps this fraction of the successful calls of a method, failed calls are always logged.
+	Sample map[string]float64 `yaml:"sample"`
+	// Payloads adds the redacted request and response to the call records.
+	Payloads bool `yaml:"payloads"`
+}
+
+func (l Logging) validate() error {
+	switch l.Format {
+	case "text", "json":
+	default:
+		return fmt.Errorf("unknown logging format %q", l.Format)
+	}
+
+	for method, rate := range l.Sample {
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("logging sample of %v must be between 0 and 1, got %v", method, rate)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,19 @@
+package config
+
+// Metadata selects the built-in metadata providers attached to every call.
+type Metadata struct {
+	RequestID     bool              `yaml:"request_id"`
+	ClientVersion bool              `yaml:"client_version"`
+	Host          bool              `yaml:"host"`
+	Tenant        string            `yaml:"tenant"`
+	Static        map[string]string `yaml:"static"`
+	Propagate     Propagation       `yaml:"propagate"`
+}
+
+// Propagation is the allow-list of incoming metadata copied onto outgoing calls
+// when the client runs inside a gRPC server. authorization is not in the defaults, it
+// would replace or duplicate the credentials of the client.
+type Propagation struct {
+	Keys     []string `yaml:"keys"`
+	Prefixes []string `yaml:"prefixes"`
+}
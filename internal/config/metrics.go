@@ -0,0 +1,9 @@
+package config
+
+// Metrics configures the Prometheus text metrics of the client calls.
+type Metrics struct {
+	// Listen serves the metrics on http://<listen>/metrics when set, e.g. localhost:9464.
+	Listen string `yaml:"listen"`
+	// File receives a dump of the metrics when the client exits.
+	File string `yaml:"file"`
+}
@@ -0,0 +1,23 @@
+package config
+
+import "fmt"
+
+// Mock configures the mock server command.
+type Mock struct {
+	// Listen is a host:port or unix:/path/to/socket address.
+	Listen string `yaml:"listen"`
+	// Match is body, to serve recorded calls with equal requests, or method, to serve any call of the method.
+	Match string `yaml:"match"`
+	// Ignore are request fields left out of body matching, as full field names or path.Match patterns.
+	Ignore []string `yaml:"ignore"`
+}
+
+func (m Mock) validate() error {
+	switch m.Match {
+	case "body", "method":
+	default:
+		return fmt.Errorf("unknown mock match mode %q", m.Match)
+	}
+
+	return nil
+}
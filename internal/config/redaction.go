@@ -0,0 +1,30 @@
+package config
+
+import (
+	"fmt"
+	"path"
+)
+
+// Redaction lists the payload fields masked before a message is logged or recorded.
+type Redaction struct {
+	// Fields are fully-qualified field names or path.Match patterns, e.g. bank.TransferRequest.amount or *.account_number.
+	Fields []string `yaml:"fields"`
+	// Numbers is zero or hash.
+	Numbers string `yaml:"numbers"`
+}
+
+func (r Redaction) validate() error {
+	switch r.Numbers {
+	case "zero", "hash":
+	default:
+		return fmt.Errorf("unknown redaction numbers mode %q", r.Numbers)
+	}
+
+	for _, f := range r.Fields {
+		if _, err := path.Match(f, ""); err != nil {
+			return fmt.Errorf("invalid redaction field pattern %q: %w", f, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,8 @@
+package config
+
+// Replay configures the replay command.
+type Replay struct {
+	// Ignore are volatile response fields left out of the comparison, as full field
+	// names or path.Match patterns.
+	Ignore []string `yaml:"ignore"`
+}
@@ -0,0 +1,46 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TLS configures the transport security of the connection to Target, which is plaintext when disabled.
+type TLS struct {
+	Enabled bool `yaml:"enabled"`
+	// CAFile is a PEM bundle of trusted CAs, the system pool when empty.
+	CAFile string `yaml:"ca_file"`
+	// CertFile and KeyFile are the client certificate and key for mTLS.
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+	// ServerName overrides the host name checked against the server certificate.
+	ServerName string `yaml:"server_name"`
+	// MinVersion is 1.2 or 1.3.
+	MinVersion string `yaml:"min_version"`
+	// ServerURISANs pins the server to one of these URI SANs, e.g. spiffe://bank.example/server.
+	ServerURISANs []string `yaml:"server_uri_sans"`
+}
+
+func (t TLS) validate() error {
+	if !t.Enabled {
+		return nil
+	}
+
+	switch t.MinVersion {
+	case "", "1.2", "1.3":
+	default:
+		return fmt.Errorf("unknown tls min_version %q, expected 1.2 or 1.3", t.MinVersion)
+	}
+
+	if (t.CertFile == "") != (t.KeyFile == "") {
+		return fmt.Errorf("tls needs both cert_file and key_file for mTLS")
+	}
+
+	for _, san := range t.ServerURISANs {
+		if !strings.Contains(san, "://") {
+			return fmt.Errorf("tls server_uri_sans %q is not a URI", san)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,38 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// Tracing configures the spans of the client calls.
+type Tracing struct {
+	// Exporters are stdout, JSON lines on standard output, and otlp. Tracing is off without exporters.
+	Exporters []string `yaml:"exporters"`
+	// Service names the client in the spans.
+	Service string `yaml:"service"`
+	OTLP    OTLP   `yaml:"otlp"`
+}
+
+type OTLP struct {
+	// Endpoint is the traces URL of an OTLP/HTTP collector.
+	Endpoint string            `yaml:"endpoint"`
+	Headers  map[string]string `yaml:"headers"`
+	Timeout  time.Duration     `yaml:"timeout"`
+}
+
+func (t Tracing) validate() error {
+	for _, e := range t.Exporters {
+		switch e {
+		case "stdout":
+		case "otlp":
+			if t.OTLP.Endpoint == "" {
+				return fmt.Errorf("tracing otlp exporter needs an endpoint")
+			}
+		default:
+			return fmt.Errorf("unknown tracing exporter %q", e)
+		}
+	}
+
+	return nil
+}
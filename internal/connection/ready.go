@@ -0,0 +1,33 @@
+package connection
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+)
+
+// WaitUntilReady makes conn connect and waits until it is ready. Transient failures are
+// retried by grpc until ctx is done, the error then carries the last state.
+func WaitUntilReady(ctx context.Context, conn *grpc.ClientConn) error {
+	conn.Connect()
+
+	for {
+		state := conn.GetState()
+
+		switch state {
+		case connectivity.Ready:
+			return nil
+		case connectivity.Shutdown:
+			return fmt.Errorf("connection to %v is closed", conn.Target())
+		case connectivity.Idle:
+			// idle again after a failure, e.g. the resolver returned no address
+			conn.Connect()
+		}
+
+		if !conn.WaitForStateChange(ctx, state) {
+			return fmt.Errorf("connection to %v not ready, still %v: %w", conn.Target(), state, ctx.Err())
+		}
+	}
+}
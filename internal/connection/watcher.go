@@ -0,0 +1,61 @@
+package connection
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+)
+
+// StateChange is a connectivity transition of a connection.
+type StateChange struct {
+	Target string
+	From   connectivity.State
+	To     connectivity.State
+	At     time.Time
+	// In is how long the connection was in From.
+	In time.Duration
+}
+
+type WatcherSettings struct {
+	// OnChange receives every transition, in order, from the watcher goroutine.
+	OnChange []func(StateChange)
+}
+
+// Watch logs the state transitions of conn and passes them to the OnChange funcs until
+// ctx is done or conn is closed. It does not make an idle conn connect.
+func Watch(ctx context.Context, conn *grpc.ClientConn, settings WatcherSettings) {
+	go func() {
+		state := conn.GetState()
+		since := time.Now()
+
+		for {
+			if !conn.WaitForStateChange(ctx, state) {
+				return
+			}
+
+			now := time.Now()
+			change := StateChange{
+				Target: conn.Target(),
+				From:   state,
+				To:     conn.GetState(),
+				At:     now,
+				In:     now.Sub(since),
+			}
+
+			log.Printf("Connection to %v: %v -> %v after %v\n", change.Target, change.From, change.To, change.In.Round(time.Millisecond))
+
+			for _, f := range settings.OnChange {
+				f(change)
+			}
+
+			if change.To == connectivity.Shutdown {
+				return
+			}
+
+			state, since = change.To, now
+		}
+	}()
+}
@@ -0,0 +1,114 @@
+package fileresolver
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"os"
+
+	"github.com/viquitorreis/my-grpc-go-client/internal/balancing"
+	"google.golang.org/grpc/resolver"
+	"gopkg.in/yaml.v3"
+)
+
+// File is the content of an endpoints file, in YAML or JSON:
+//
+//	endpoints:
+//	  - address: 10.0.0.11:9090
+//	    weight: 3
+//	    attributes: {zone: eu-west-1a}
+//	  - 10.0.0.12:9090
+type File struct {
+	Endpoints []Endpoint `yaml:"endpoints"`
+}
+
+// Endpoint is written as host:port, or as a mapping to set its weight and attributes.
+type Endpoint struct {
+	Address    string            `yaml:"address"`
+	Weight     uint32            `yaml:"weight"`
+	Attributes map[string]string `yaml:"attributes"`
+}
+
+func (e *Endpoint) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		return value.Decode(&e.Address)
+	}
+
+	type endpoint Endpoint
+
+	return value.Decode((*endpoint)(e))
+}
+
+// Parse reads an endpoints file, JSON being valid YAML.
+func Parse(b []byte) (*File, error) {
+	var f File
+	if err := yaml.Unmarshal(b, &f); err != nil {
+		return nil, err
+	}
+
+	if len(f.Endpoints) == 0 {
+		return nil, errors.New("no endpoints")
+	}
+
+	for i, e := range f.Endpoints {
+		if _, _, err := net.SplitHostPort(e.Address); err != nil {
+			return nil, fmt.Errorf("endpoint %d: %w", i, err)
+		}
+	}
+
+	return &f, nil
+}
+
+// ReadFile reads and parses the endpoints file at path.
+func ReadFile(path string) (*File, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := Parse(b)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", path, err)
+	}
+
+	return f, nil
+}
+
+// State returns the resolver state of the endpoints. The weights and attributes are balancer
+// attributes, which subconns are not keyed by, so changing them does not reconnect.
+func (f *File) State() resolver.State {
+	addrs := make([]resolver.Address, 0, len(f.Endpoints))
+
+	for _, e := range f.Endpoints {
+		addr := resolver.Address{Addr: e.Address}
+
+		if e.Weight > 0 {
+			addr = balancing.SetWeight(addr, e.Weight)
+		}
+
+		if len(e.Attributes) > 0 {
+			addr.BalancerAttributes = addr.BalancerAttributes.WithValue(attributesKey{}, attributes(e.Attributes))
+		}
+
+		addrs = append(addrs, addr)
+	}
+
+	return resolver.State{Addresses: addrs}
+}
+
+type attributesKey struct{}
+
+// attributes implements Equal, maps can not be compared by resolver.Address.Equal.
+type attributes map[string]string
+
+func (a attributes) Equal(o any) bool {
+	oa, ok := o.(attributes)
+	return ok && maps.Equal(a, oa)
+}
+
+// Attributes returns the attributes of the endpoint of addr, nil when it has none.
+func Attributes(addr resolver.Address) map[string]string {
+	a, _ := addr.BalancerAttributes.Value(attributesKey{}).(attributes)
+	return a
+}
@@ -0,0 +1,152 @@
+package fileresolver
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"google.golang.org/grpc/resolver"
+)
+
+// Scheme resolves targets such as file:///etc/bank-endpoints.json. The host of the target,
+// e.g. file://bank.internal/etc/bank-endpoints.json, is used as the authority of the calls.
+// Without a host the authority is localhost, as for unix targets, so tls.server_name has to
+// be configured for TLS to verify the endpoints.
+const Scheme = "file"
+
+func init() {
+	resolver.Register(NewBuilder(time.Second))
+}
+
+// NewBuilder returns a builder checking the endpoints files for changes every interval.
+func NewBuilder(interval time.Duration) resolver.Builder {
+	return &builder{interval: interval}
+}
+
+type builder struct {
+	interval time.Duration
+}
+
+func (b *builder) Scheme() string {
+	return Scheme
+}
+
+func (b *builder) OverrideAuthority(target resolver.Target) string {
+	if target.URL.Host != "" {
+		return target.URL.Host
+	}
+
+	return "localhost"
+}
+
+func (b *builder) Build(target resolver.Target, cc resolver.ClientConn, _ resolver.BuildOptions) (resolver.Resolver, error) {
+	if target.URL.Path == "" {
+		return nil, fmt.Errorf("file resolver: no path in target %v", target.URL.String())
+	}
+
+	r := &fileResolver{
+		path:       target.URL.Path,
+		cc:         cc,
+		resolveNow: make(chan struct{}, 1),
+		done:       make(chan struct{}),
+	}
+
+	r.load()
+
+	go r.watch(b.interval)
+
+	return r, nil
+}
+
+// fileResolver pushes the endpoints of its file to the ClientConn whenever the file changes.
+// A file that can not be read or parsed is reported with ReportError, the ClientConn then
+// keeps the previous endpoints.
+type fileResolver struct {
+	path       string
+	cc         resolver.ClientConn
+	resolveNow chan struct{}
+	done       chan struct{}
+
+	// owned by the watch goroutine after Build
+	modTime time.Time
+	size    int64
+	content []byte
+	err     error
+}
+
+func (r *fileResolver) watch(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.done:
+			return
+		case <-ticker.C:
+		case <-r.resolveNow:
+		}
+
+		r.load()
+	}
+}
+
+func (r *fileResolver) load() {
+	info, err := os.Stat(r.path)
+	if err != nil {
+		r.fail(err)
+		return
+	}
+
+	// a failed file is read again, grpc asks for it while the channel is failing
+	if r.err == nil && info.ModTime().Equal(r.modTime) && info.Size() == r.size {
+		return
+	}
+
+	r.modTime, r.size = info.ModTime(), info.Size()
+
+	b, err := os.ReadFile(r.path)
+	if err != nil {
+		r.fail(err)
+		return
+	}
+
+	if r.err == nil && bytes.Equal(b, r.content) {
+		return
+	}
+
+	f, err := Parse(b)
+	if err != nil {
+		r.fail(fmt.Errorf("%v: %w", r.path, err))
+		return
+	}
+
+	r.content, r.err = b, nil
+
+	log.Printf("Resolved %d endpoints from %v\n", len(f.Endpoints), r.path)
+
+	if err := r.cc.UpdateState(f.State()); err != nil {
+		log.Printf("Endpoints of %v were rejected: %v\n", r.path, err)
+	}
+}
+
+func (r *fileResolver) fail(err error) {
+	if r.err == nil || r.err.Error() != err.Error() {
+		log.Println("Failed to resolve endpoints: ", err)
+	}
+
+	r.err = err
+	r.cc.ReportError(err)
+}
+
+func (r *fileResolver) ResolveNow(resolver.ResolveNowOptions) {
+	select {
+	case r.resolveNow <- struct{}{}:
+	default:
+	}
+}
+
+func (r *fileResolver) Close() {
+	close(r.done)
+}
@@ -0,0 +1,190 @@
+package fileresolver
+
+import (
+	"errors"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/viquitorreis/my-grpc-go-client/internal/balancing"
+	"google.golang.org/grpc/resolver"
+)
+
+// clientConn records what the resolver pushes, the methods it does not use are left nil.
+// A failing file is reported again on every check, errors are dropped once errs is full.
+type clientConn struct {
+	resolver.ClientConn
+
+	states chan resolver.State
+	errs   chan error
+}
+
+func newClientConn() *clientConn {
+	return &clientConn{
+		states: make(chan resolver.State, 10),
+		errs:   make(chan error, 10),
+	}
+}
+
+func (cc *clientConn) UpdateState(s resolver.State) error {
+	cc.states <- s
+	return nil
+}
+
+func (cc *clientConn) ReportError(err error) {
+	select {
+	case cc.errs <- err:
+	default:
+	}
+}
+
+// state waits for the next state, skipping the errors reported until then.
+func (cc *clientConn) state(t *testing.T) resolver.State {
+	t.Helper()
+
+	timeout := time.After(5 * time.Second)
+
+	for {
+		select {
+		case s := <-cc.states:
+			return s
+		case <-cc.errs:
+		case <-timeout:
+			t.Fatal("no state pushed")
+		}
+	}
+}
+
+// error waits for an error matching target, failing on any state pushed meanwhile.
+func (cc *clientConn) error(t *testing.T, target error) {
+	t.Helper()
+
+	timeout := time.After(5 * time.Second)
+
+	for {
+		select {
+		case err := <-cc.errs:
+			if target == nil || errors.Is(err, target) {
+				return
+			}
+		case s := <-cc.states:
+			t.Fatalf("unexpected state: %v", s)
+		case <-timeout:
+			t.Fatalf("no error reported matching %v", target)
+		}
+	}
+}
+
+func target(t *testing.T, raw string) resolver.Target {
+	t.Helper()
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return resolver.Target{URL: *u}
+}
+
+func write(t *testing.T, path, content string) {
+	t.Helper()
+
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func addresses(s resolver.State) []string {
+	addrs := make([]string, len(s.Addresses))
+	for i, a := range s.Addresses {
+		addrs[i] = a.Addr
+	}
+
+	return addrs
+}
+
+func TestOverrideAuthority(t *testing.T) {
+	tests := []struct {
+		target string
+		want   string
+	}{
+		{"file://bank.internal/etc/bank-endpoints.json", "bank.internal"},
+		{"file:///etc/bank-endpoints.json", "localhost"},
+	}
+
+	b := NewBuilder(time.Second).(resolver.AuthorityOverrider)
+
+	for _, tt := range tests {
+		if got := b.OverrideAuthority(target(t, tt.target)); got != tt.want {
+			t.Errorf("OverrideAuthority(%v) = %q, want %q", tt.target, got, tt.want)
+		}
+	}
+}
+
+func TestResolverFollowsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "endpoints.yaml")
+	write(t, path, "endpoints: [10.0.0.11:9090]\n")
+
+	cc := newClientConn()
+
+	r, err := NewBuilder(10*time.Millisecond).Build(target(t, "file://"+path), cc, resolver.BuildOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	if got := addresses(cc.state(t)); len(got) != 1 || got[0] != "10.0.0.11:9090" {
+		t.Fatalf("addresses = %v, want [10.0.0.11:9090]", got)
+	}
+
+	write(t, path, `
+endpoints:
+  - address: 10.0.0.11:9090
+    weight: 3
+    attributes: {zone: a}
+  - 10.0.0.12:9090
+`)
+
+	s := cc.state(t)
+	if got := addresses(s); len(got) != 2 || got[0] != "10.0.0.11:9090" || got[1] != "10.0.0.12:9090" {
+		t.Fatalf("addresses = %v, want [10.0.0.11:9090 10.0.0.12:9090]", got)
+	}
+
+	if w := balancing.Weight(s.Addresses[0]); w != 3 {
+		t.Fatalf("weight = %d, want 3", w)
+	}
+
+	if zone := Attributes(s.Addresses[0])["zone"]; zone != "a" {
+		t.Fatalf("zone = %q, want a", zone)
+	}
+
+	write(t, path, "endpoints: [not an address]\n")
+	cc.error(t, nil)
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	cc.error(t, fs.ErrNotExist)
+
+	// the file comes back as it was before failing
+	write(t, path, "endpoints: [10.0.0.11:9090]\n")
+
+	if got := addresses(cc.state(t)); len(got) != 1 || got[0] != "10.0.0.11:9090" {
+		t.Fatalf("addresses = %v, want [10.0.0.11:9090]", got)
+	}
+}
+
+func TestBuildReportsMissingFile(t *testing.T) {
+	cc := newClientConn()
+
+	r, err := NewBuilder(time.Hour).Build(target(t, "file://"+filepath.Join(t.TempDir(), "missing.json")), cc, resolver.BuildOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	cc.error(t, fs.ErrNotExist)
+}
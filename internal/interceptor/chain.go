@@ -0,0 +1,170 @@
+package interceptor
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/viquitorreis/my-grpc-go-client/internal/config"
+	"google.golang.org/grpc"
+)
+
+// Chainable is an interceptor that can be declared in the chain of the config. Either side may
+// be nil for interceptors that only handle one kind of call, or that are turned off.
+type Chainable struct {
+	Unary  grpc.UnaryClientInterceptor
+	Stream grpc.StreamClientInterceptor
+}
+
+// ChainFactory builds a Chainable, decode fills its options struct from the config entry.
+type ChainFactory func(decode func(options any) error) (Chainable, error)
+
+// ChainRegistry maps the interceptor names used in the config to their factories.
+type ChainRegistry struct {
+	factories map[string]ChainFactory
+}
+
+func NewChainRegistry() *ChainRegistry {
+	return &ChainRegistry{factories: make(map[string]ChainFactory)}
+}
+
+func (r *ChainRegistry) Register(name string, factory ChainFactory) {
+	r.factories[name] = factory
+}
+
+func (r *ChainRegistry) Names() []string {
+	names := make([]string, 0, len(r.factories))
+	for name := range r.factories {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Build creates the unary and stream chains declared by entries, in their order.
+func (r *ChainRegistry) Build(entries []config.Interceptor) ([]grpc.UnaryClientInterceptor, []grpc.StreamClientInterceptor, error) {
+	var (
+		unary  []grpc.UnaryClientInterceptor
+		stream []grpc.StreamClientInterceptor
+	)
+
+	for _, entry := range entries {
+		factory, ok := r.factories[entry.Name]
+		if !ok {
+			return nil, nil, fmt.Errorf("unknown interceptor %q, known ones are %v", entry.Name, strings.Join(r.Names(), ", "))
+		}
+
+		c, err := factory(entry.DecodeOptions)
+		if err != nil {
+			return nil, nil, fmt.Errorf("interceptor %v: %w", entry.Name, err)
+		}
+
+		matcher := NewMethodMatcher(entry.Methods)
+
+		if c.Unary != nil {
+			unary = append(unary, MatchUnaryClientInterceptor(matcher, c.Unary))
+		}
+
+		if c.Stream != nil {
+			stream = append(stream, MatchStreamClientInterceptor(matcher, c.Stream))
+		}
+	}
+
+	return unary, stream, nil
+}
+
+// MethodMatcher selects methods with path.Match patterns, patterns starting with ! exclude.
+// A leading * also matches the leading slash, so */TransferMultiple matches /bank.BankService/TransferMultiple.
+type MethodMatcher struct {
+	include []string
+	exclude []string
+}
+
+func NewMethodMatcher(patterns []string) *MethodMatcher {
+	m := &MethodMatcher{}
+
+	for _, p := range patterns {
+		if exclude, ok := strings.CutPrefix(p, "!"); ok {
+			m.exclude = append(m.exclude, exclude)
+		} else {
+			m.include = append(m.include, p)
+		}
+	}
+
+	return m
+}
+
+// All is true when the matcher has no patterns, i.e. it matches every method.
+func (m *MethodMatcher) All() bool {
+	return len(m.include) == 0 && len(m.exclude) == 0
+}
+
+func (m *MethodMatcher) Match(method string) bool {
+	for _, p := range m.exclude {
+		if matchMethod(p, method) {
+			return false
+		}
+	}
+
+	if len(m.include) == 0 {
+		return true
+	}
+
+	for _, p := range m.include {
+		if matchMethod(p, method) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matchMethod(pattern, method string) bool {
+	if ok, _ := path.Match(pattern, method); ok {
+		return true
+	}
+
+	ok, _ := path.Match(pattern, strings.TrimPrefix(method, "/"))
+
+	return ok
+}
+
+// MatchUnaryClientInterceptor runs next only on the methods matched by m.
+func MatchUnaryClientInterceptor(m *MethodMatcher, next grpc.UnaryClientInterceptor) grpc.UnaryClientInterceptor {
+	if m.All() {
+		return next
+	}
+
+	return func(
+		ctx context.Context, method string, req, reply any,
+		cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption,
+	) error {
+		if !m.Match(method) {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		return next(ctx, method, req, reply, cc, invoker, opts...)
+	}
+}
+
+// MatchStreamClientInterceptor runs next only on the methods matched by m.
+func MatchStreamClientInterceptor(m *MethodMatcher, next grpc.StreamClientInterceptor) grpc.StreamClientInterceptor {
+	if m.All() {
+		return next
+	}
+
+	return func(
+		ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn,
+		method string, streamer grpc.Streamer, opts ...grpc.CallOption,
+	) (grpc.ClientStream, error) {
+		if !m.Match(method) {
+			return streamer(ctx, desc, cc, method, opts...)
+		}
+
+		return next(ctx, desc, cc, method, streamer, opts...)
+	}
+}
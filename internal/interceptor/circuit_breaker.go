@@ -0,0 +1,125 @@
+package interceptor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/sony/gobreaker"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrCircuitOpen is wrapped by the error returned when the breaker rejects a call.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// circuitOpenError keeps codes.Unavailable for gRPC callers while still matching ErrCircuitOpen.
+type circuitOpenError struct {
+	st *status.Status
+}
+
+func (e *circuitOpenError) Error() string {
+	return e.st.Err().Error()
+}
+
+func (e *circuitOpenError) GRPCStatus() *status.Status {
+	return e.st
+}
+
+func (e *circuitOpenError) Unwrap() error {
+	return ErrCircuitOpen
+}
+
+// callerDoneError is a failure of a call whose caller gave up, it says nothing about the server.
+type callerDoneError struct {
+	err error
+}
+
+func (e *callerDoneError) Error() string {
+	return e.err.Error()
+}
+
+// BreakerSuccessful is meant for gobreaker.Settings.IsSuccessful: only the errors telling that
+// the server is down, overloaded or too slow count as failures, not the ones caused by the
+// request itself or by the caller giving up.
+func BreakerSuccessful(err error) bool {
+	var done *callerDoneError
+	if errors.As(err, &done) {
+		return true
+	}
+
+	switch status.Code(err) {
+	case codes.Unavailable, codes.ResourceExhausted, codes.DeadlineExceeded:
+		return false
+	}
+
+	return true
+}
+
+// breakerResult marks err when the caller context is done, see BreakerSuccessful.
+func breakerResult(ctx context.Context, err error) error {
+	if err != nil && ctx.Err() != nil {
+		return &callerDoneError{err: err}
+	}
+
+	return err
+}
+
+func breakerError(err error) error {
+	var done *callerDoneError
+	if errors.As(err, &done) {
+		return done.err
+	}
+
+	if errors.Is(err, gobreaker.ErrOpenState) || errors.Is(err, gobreaker.ErrTooManyRequests) {
+		return &circuitOpenError{
+			st: status.New(codes.Unavailable, fmt.Sprintf("%v: %v", ErrCircuitOpen, err)),
+		}
+	}
+
+	return err
+}
+
+// CircuitBreakerUnaryClientInterceptor runs the unary calls of methods through cb, every
+// unary call when methods is empty.
+func CircuitBreakerUnaryClientInterceptor(cb *gobreaker.CircuitBreaker, methods ...string) grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context, method string, req, reply any,
+		cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption,
+	) error {
+		if len(methods) > 0 && !slices.Contains(methods, method) {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		_, err := cb.Execute(func() (interface{}, error) {
+			return nil, breakerResult(ctx, invoker(ctx, method, req, reply, cc, opts...))
+		})
+
+		return breakerError(err)
+	}
+}
+
+// CircuitBreakerStreamClientInterceptor runs the creation of the streams of methods through
+// cb, every stream when methods is empty.
+func CircuitBreakerStreamClientInterceptor(cb *gobreaker.CircuitBreaker, methods ...string) grpc.StreamClientInterceptor {
+	return func(
+		ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn,
+		method string, streamer grpc.Streamer, opts ...grpc.CallOption,
+	) (grpc.ClientStream, error) {
+		if len(methods) > 0 && !slices.Contains(methods, method) {
+			return streamer(ctx, desc, cc, method, opts...)
+		}
+
+		clientStream, err := cb.Execute(func() (interface{}, error) {
+			clientStream, err := streamer(ctx, desc, cc, method, opts...)
+			return clientStream, breakerResult(ctx, err)
+		})
+		if err != nil {
+			return nil, breakerError(err)
+		}
+
+		return clientStream.(grpc.ClientStream), nil
+	}
+}
@@ -0,0 +1,187 @@
+package interceptor
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/viquitorreis/my-grpc-go-client/internal/compression"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+type CompressionSettings struct {
+	// Codec is a codec registered with compression.Register, gzip by default.
+	Codec string
+	// MinSize is the size in bytes a request must reach to be compressed. Client and bidi
+	// streams are decided on their first message. Server streams are opened before their
+	// request is known, they are only compressed with a MinSize of 0, which also makes a
+	// grpc-go server compress its responses.
+	MinSize int
+}
+
+// Compression compresses the calls whose requests reach a size threshold.
+type Compression struct {
+	settings CompressionSettings
+}
+
+func NewCompression(settings CompressionSettings) (*Compression, error) {
+	if settings.Codec == "" {
+		settings.Codec = "gzip"
+	}
+
+	if !compression.Registered(settings.Codec) {
+		return nil, fmt.Errorf("unknown compression codec %q, registered: %v", settings.Codec, compression.Names())
+	}
+
+	return &Compression{settings: settings}, nil
+}
+
+// options adds the compressor to opts when msg is large enough, unless the caller chose one.
+func (c *Compression) options(msg any, opts []grpc.CallOption) []grpc.CallOption {
+	for _, opt := range opts {
+		if _, ok := opt.(grpc.CompressorCallOption); ok {
+			return opts
+		}
+	}
+
+	if c.settings.MinSize > 0 {
+		m, ok := msg.(proto.Message)
+		if !ok || proto.Size(m) < c.settings.MinSize {
+			return opts
+		}
+	}
+
+	return append(opts[:len(opts):len(opts)], grpc.UseCompressor(c.settings.Codec))
+}
+
+func (c *Compression) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context, method string, req, reply any,
+		cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption,
+	) error {
+		return invoker(ctx, method, req, reply, cc, c.options(req, opts)...)
+	}
+}
+
+// StreamClientInterceptor opens client and bidi streams on their first SendMsg when they have
+// a MinSize, to size the first message. Anything else called before, e.g. RecvMsg on a bidi
+// stream, opens it uncompressed. The errors opening those streams are returned by that call,
+// not by the streamer, so the interceptor must sit below retry, circuit_breaker and
+// concurrency_limit, which only see the streamer. The other streams are opened right away.
+func (c *Compression) StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(
+		ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn,
+		method string, streamer grpc.Streamer, opts ...grpc.CallOption,
+	) (grpc.ClientStream, error) {
+		if !desc.ClientStreams || c.settings.MinSize <= 0 {
+			return streamer(ctx, desc, cc, method, c.options(nil, opts)...)
+		}
+
+		// like any grpc stream, it is released once ctx is canceled or the stream ends
+		ctx, cancel := context.WithCancel(ctx)
+
+		return &compressionClientStream{
+			compression: c,
+			ctx:         ctx,
+			cancel:      cancel,
+			open: func(opts2 []grpc.CallOption) (grpc.ClientStream, error) {
+				return streamer(ctx, desc, cc, method, opts2...)
+			},
+			opts: opts,
+		}, nil
+	}
+}
+
+type compressionClientStream struct {
+	compression *Compression
+	// ctx is the context of the stream until it is open, it ends with the stream.
+	ctx    context.Context
+	cancel context.CancelFunc
+	open   func([]grpc.CallOption) (grpc.ClientStream, error)
+	opts   []grpc.CallOption
+
+	mu     sync.Mutex
+	stream grpc.ClientStream
+	err    error
+}
+
+// start opens the stream, compressed when first is large enough.
+func (s *compressionClientStream) start(first any) (grpc.ClientStream, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stream != nil || s.err != nil {
+		return s.stream, s.err
+	}
+
+	s.stream, s.err = s.open(s.compression.options(first, s.opts))
+	if s.err != nil {
+		s.cancel()
+		return nil, s.err
+	}
+
+	go func() {
+		<-s.stream.Context().Done()
+		s.cancel()
+	}()
+
+	return s.stream, nil
+}
+
+// opened returns the stream if it is open.
+func (s *compressionClientStream) opened() grpc.ClientStream {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.stream
+}
+
+func (s *compressionClientStream) SendMsg(msg any) error {
+	stream, err := s.start(msg)
+	if err != nil {
+		return err
+	}
+
+	return stream.SendMsg(msg)
+}
+
+func (s *compressionClientStream) RecvMsg(msg any) error {
+	stream, err := s.start(nil)
+	if err != nil {
+		return err
+	}
+
+	return stream.RecvMsg(msg)
+}
+
+func (s *compressionClientStream) Header() (metadata.MD, error) {
+	stream, err := s.start(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return stream.Header()
+}
+
+func (s *compressionClientStream) Trailer() metadata.MD {
+	if stream := s.opened(); stream != nil {
+		return stream.Trailer()
+	}
+
+	return nil
+}
+
+func (s *compressionClientStream) CloseSend() error {
+	stream, err := s.start(nil)
+	if err != nil {
+		return err
+	}
+
+	return stream.CloseSend()
+}
+
+func (s *compressionClientStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,295 @@
+package interceptor
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ConcurrencyLimiterSettings configures the AIMD (additive increase,
+// multiplicative decrease) limiter. Zero values fall back to sane defaults.
+type ConcurrencyLimiterSettings struct {
+	InitialLimit int
+	MinLimit     int
+	MaxLimit     int
+	// BackoffRatio multiplies the limit when latency or overload errors rise (0 < ratio < 1).
+	BackoffRatio float64
+	// Tolerance is how much the sampled RTT may exceed the smoothed RTT before it counts as a latency increase.
+	Tolerance float64
+	// Smoothing is the weight of each new RTT sample on the smoothed RTT (0 < smoothing <= 1).
+	Smoothing float64
+	// OnLimitChange is called every time the integer limit of a method changes.
+	OnLimitChange func(method string, from, to int)
+}
+
+// ConcurrencyLimiter keeps one adaptive in-flight limit per method. Calls that
+// exceed the current limit fail fast with codes.ResourceExhausted.
+type ConcurrencyLimiter struct {
+	settings ConcurrencyLimiterSettings
+
+	mu      sync.Mutex
+	methods map[string]*methodLimit
+}
+
+type methodLimit struct {
+	limit       float64
+	inFlight    int
+	smoothedRTT time.Duration
+}
+
+func NewConcurrencyLimiter(settings ConcurrencyLimiterSettings) *ConcurrencyLimiter {
+	if settings.MinLimit <= 0 {
+		settings.MinLimit = 1
+	}
+
+	if settings.MaxLimit <= 0 {
+		settings.MaxLimit = 200
+	}
+
+	if settings.InitialLimit <= 0 {
+		settings.InitialLimit = 10
+	}
+
+	settings.InitialLimit = min(max(settings.InitialLimit, settings.MinLimit), settings.MaxLimit)
+
+	if settings.BackoffRatio <= 0 || settings.BackoffRatio >= 1 {
+		settings.BackoffRatio = 0.9
+	}
+
+	if settings.Tolerance < 1 {
+		settings.Tolerance = 1.5
+	}
+
+	if settings.Smoothing <= 0 || settings.Smoothing > 1 {
+		settings.Smoothing = 0.2
+	}
+
+	return &ConcurrencyLimiter{
+		settings: settings,
+		methods:  make(map[string]*methodLimit),
+	}
+}
+
+// Limit returns the current in-flight limit for method.
+func (l *ConcurrencyLimiter) Limit(method string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return int(l.methodLocked(method).limit)
+}
+
+// Limits returns a snapshot of the current in-flight limit of every method seen so far.
+func (l *ConcurrencyLimiter) Limits() map[string]int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	limits := make(map[string]int, len(l.methods))
+	for method, m := range l.methods {
+		limits[method] = int(m.limit)
+	}
+
+	return limits
+}
+
+// InFlight returns how many calls to method are currently running.
+func (l *ConcurrencyLimiter) InFlight(method string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.methodLocked(method).inFlight
+}
+
+func (l *ConcurrencyLimiter) methodLocked(method string) *methodLimit {
+	m, ok := l.methods[method]
+	if !ok {
+		m = &methodLimit{limit: float64(l.settings.InitialLimit)}
+		l.methods[method] = m
+	}
+
+	return m
+}
+
+func (l *ConcurrencyLimiter) acquire(method string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	m := l.methodLocked(method)
+	if m.inFlight >= int(m.limit) {
+		return status.Errorf(codes.ResourceExhausted, "concurrency limit of %d reached for %v", int(m.limit), method)
+	}
+
+	m.inFlight++
+
+	return nil
+}
+
+func (l *ConcurrencyLimiter) release(method string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.methodLocked(method).inFlight--
+}
+
+// sample feeds one observation back into the limit of method. rtt is ignored when zero.
+func (l *ConcurrencyLimiter) sample(method string, rtt time.Duration, code codes.Code) {
+	l.mu.Lock()
+
+	m := l.methodLocked(method)
+	before := int(m.limit)
+
+	overloaded := code == codes.DeadlineExceeded || code == codes.ResourceExhausted
+
+	if rtt > 0 {
+		if m.smoothedRTT == 0 {
+			m.smoothedRTT = rtt
+		}
+
+		if float64(rtt) > float64(m.smoothedRTT)*l.settings.Tolerance {
+			overloaded = true
+		}
+
+		m.smoothedRTT = time.Duration(l.settings.Smoothing*float64(rtt) + (1-l.settings.Smoothing)*float64(m.smoothedRTT))
+	}
+
+	if overloaded {
+		m.limit = math.Max(m.limit*l.settings.BackoffRatio, float64(l.settings.MinLimit))
+	} else if code == codes.OK {
+		// one slot per "window" of successful calls
+		m.limit = math.Min(m.limit+1/m.limit, float64(l.settings.MaxLimit))
+	}
+
+	after := int(m.limit)
+	l.mu.Unlock()
+
+	if before != after && l.settings.OnLimitChange != nil {
+		l.settings.OnLimitChange(method, before, after)
+	}
+}
+
+func (l *ConcurrencyLimiter) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context, method string, req, reply any,
+		cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption,
+	) error {
+		if err := l.acquire(method); err != nil {
+			return err
+		}
+		defer l.release(method)
+
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		l.sample(method, time.Since(start), status.Code(err))
+
+		return err
+	}
+}
+
+func (l *ConcurrencyLimiter) StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(
+		ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn,
+		method string, streamer grpc.Streamer, opts ...grpc.CallOption,
+	) (grpc.ClientStream, error) {
+		if err := l.acquire(method); err != nil {
+			return nil, err
+		}
+
+		start := time.Now()
+		clientStream, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			l.release(method)
+			l.sample(method, 0, status.Code(err))
+			return nil, err
+		}
+
+		limitedStream := &concurrencyLimitedStream{
+			ClientStream: clientStream,
+			limiter:      l,
+			method:       method,
+			desc:         desc,
+			start:        start,
+		}
+
+		// the stream context is cancelled once the stream finishes for any reason,
+		// so the slot is always given back even if the caller stops reading
+		go func() {
+			<-clientStream.Context().Done()
+
+			code := codes.OK
+			if errors.Is(clientStream.Context().Err(), context.DeadlineExceeded) {
+				code = codes.DeadlineExceeded
+			}
+
+			limitedStream.finish(code)
+		}()
+
+		return limitedStream, nil
+	}
+}
+
+// concurrencyLimitedStream holds a limiter slot for the whole life of the stream.
+// The RTT sample of a stream is the time until its first response message, counted from
+// CloseSend for client streams, whose response waits for the last request. Bidi streams
+// give no sample, their responses may come at any time.
+type concurrencyLimitedStream struct {
+	grpc.ClientStream
+
+	limiter *ConcurrencyLimiter
+	method  string
+	desc    *grpc.StreamDesc
+
+	mu    sync.Mutex
+	start time.Time
+
+	firstRecv sync.Once
+	done      sync.Once
+}
+
+func (s *concurrencyLimitedStream) CloseSend() error {
+	if s.desc.ClientStreams {
+		s.mu.Lock()
+		s.start = time.Now()
+		s.mu.Unlock()
+	}
+
+	return s.ClientStream.CloseSend()
+}
+
+func (s *concurrencyLimitedStream) RecvMsg(msg any) error {
+	err := s.ClientStream.RecvMsg(msg)
+	if err == nil {
+		s.firstRecv.Do(s.sample)
+		return nil
+	}
+
+	s.finish(status.Code(err))
+
+	return err
+}
+
+func (s *concurrencyLimitedStream) sample() {
+	if s.desc.ClientStreams && s.desc.ServerStreams {
+		return
+	}
+
+	s.mu.Lock()
+	rtt := time.Since(s.start)
+	s.mu.Unlock()
+
+	s.limiter.sample(s.method, rtt, codes.OK)
+}
+
+func (s *concurrencyLimitedStream) finish(code codes.Code) {
+	s.done.Do(func() {
+		s.limiter.release(s.method)
+
+		if code != codes.OK {
+			s.limiter.sample(s.method, 0, code)
+		}
+	})
+}
@@ -0,0 +1,300 @@
+package interceptor
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// StaleInfo tells the caller whether the response it got came from the fallback store.
+type StaleInfo struct {
+	Stale    bool
+	StoredAt time.Time
+	Age      time.Duration
+	// Cause is the error that made the fallback kick in.
+	Cause error
+}
+
+type staleResponseCallOption struct {
+	grpc.EmptyCallOption
+	info *StaleInfo
+}
+
+// StaleResponse returns a call option that fills info when the response of the
+// call is served by a ResponseFallback instead of the server, like grpc.Header does for metadata.
+func StaleResponse(info *StaleInfo) grpc.CallOption {
+	return staleResponseCallOption{info: info}
+}
+
+func staleInfoFromOpts(opts []grpc.CallOption) *StaleInfo {
+	for _, opt := range opts {
+		if o, ok := opt.(staleResponseCallOption); ok {
+			return o.info
+		}
+	}
+
+	return nil
+}
+
+type storedResponse struct {
+	message  proto.Message
+	storedAt time.Time
+}
+
+// ResponseFallback stores the last successful response of selected read methods,
+// keyed by method and request body, and serves it when the server is unreachable.
+// For server streams only the latest received message is stored.
+type ResponseFallback struct {
+	maxAge  time.Duration
+	methods map[string]bool
+
+	mu        sync.RWMutex
+	responses map[string]storedResponse
+}
+
+// NewResponseFallback creates a fallback for methods (full gRPC method names) that refuses
+// to serve responses older than maxAge.
+func NewResponseFallback(maxAge time.Duration, methods ...string) *ResponseFallback {
+	m := make(map[string]bool, len(methods))
+	for _, method := range methods {
+		m[method] = true
+	}
+
+	return &ResponseFallback{
+		maxAge:    maxAge,
+		methods:   m,
+		responses: make(map[string]storedResponse),
+	}
+}
+
+func (f *ResponseFallback) key(method string, req any) (string, bool) {
+	msg, ok := req.(proto.Message)
+	if !ok {
+		return "", false
+	}
+
+	b, err := proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+	if err != nil {
+		return "", false
+	}
+
+	return method + "\x00" + string(b), true
+}
+
+func (f *ResponseFallback) store(key string, msg any) {
+	m, ok := msg.(proto.Message)
+	if !ok {
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.responses[key] = storedResponse{
+		message:  proto.Clone(m),
+		storedAt: time.Now(),
+	}
+}
+
+// load copies the stored response for key into reply if it is younger than maxAge.
+func (f *ResponseFallback) load(key string, reply any, cause error, info *StaleInfo) bool {
+	m, ok := reply.(proto.Message)
+	if !ok {
+		return false
+	}
+
+	f.mu.RLock()
+	stored, ok := f.responses[key]
+	f.mu.RUnlock()
+
+	if !ok {
+		return false
+	}
+
+	age := time.Since(stored.storedAt)
+	if f.maxAge > 0 && age > f.maxAge {
+		return false
+	}
+
+	proto.Reset(m)
+	proto.Merge(m, stored.message)
+
+	if info != nil {
+		*info = StaleInfo{
+			Stale:    true,
+			StoredAt: stored.storedAt,
+			Age:      age,
+			Cause:    cause,
+		}
+	}
+
+	return true
+}
+
+// shouldFallback reports whether err means the server could not be reached.
+func shouldFallback(err error) bool {
+	if errors.Is(err, ErrCircuitOpen) {
+		return true
+	}
+
+	return status.Code(err) == codes.Unavailable
+}
+
+func (f *ResponseFallback) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context, method string, req, reply any,
+		cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption,
+	) error {
+		if !f.methods[method] {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		key, ok := f.key(method, req)
+		if !ok {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		if err == nil {
+			f.store(key, reply)
+			return nil
+		}
+
+		if shouldFallback(err) && f.load(key, reply, err, staleInfoFromOpts(opts)) {
+			return nil
+		}
+
+		return err
+	}
+}
+
+func (f *ResponseFallback) StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(
+		ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn,
+		method string, streamer grpc.Streamer, opts ...grpc.CallOption,
+	) (grpc.ClientStream, error) {
+		if !f.methods[method] || desc.ClientStreams {
+			return streamer(ctx, desc, cc, method, opts...)
+		}
+
+		fallbackStream := &fallbackClientStream{
+			fallback: f,
+			method:   method,
+			info:     staleInfoFromOpts(opts),
+			ctx:      ctx,
+		}
+
+		clientStream, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			if !shouldFallback(err) {
+				return nil, err
+			}
+
+			// the request is only known after SendMsg, so the decision is deferred to RecvMsg
+			fallbackStream.cause = err
+			return fallbackStream, nil
+		}
+
+		fallbackStream.ClientStream = clientStream
+
+		return fallbackStream, nil
+	}
+}
+
+// fallbackClientStream stores every received message as the latest value of the
+// stream. When the stream fails before anything was received, it serves the stored
+// value once and then ends the stream.
+type fallbackClientStream struct {
+	grpc.ClientStream
+
+	fallback *ResponseFallback
+	method   string
+	info     *StaleInfo
+	ctx      context.Context
+
+	key      string
+	hasKey   bool
+	received bool
+	served   bool
+	// cause is set when the stream could not even be created.
+	cause error
+}
+
+func (s *fallbackClientStream) SendMsg(msg any) error {
+	if !s.hasKey {
+		s.key, s.hasKey = s.fallback.key(s.method, msg)
+	}
+
+	if s.ClientStream == nil {
+		return nil
+	}
+
+	return s.ClientStream.SendMsg(msg)
+}
+
+func (s *fallbackClientStream) RecvMsg(msg any) error {
+	if s.served {
+		return io.EOF
+	}
+
+	err := s.cause
+	if s.ClientStream != nil {
+		err = s.ClientStream.RecvMsg(msg)
+	}
+
+	if err == nil {
+		s.received = true
+		if s.hasKey {
+			s.fallback.store(s.key, msg)
+		}
+
+		return nil
+	}
+
+	if !s.received && s.hasKey && shouldFallback(err) && s.fallback.load(s.key, msg, err, s.info) {
+		s.served = true
+		return nil
+	}
+
+	return err
+}
+
+func (s *fallbackClientStream) Header() (metadata.MD, error) {
+	if s.ClientStream == nil {
+		return nil, s.cause
+	}
+
+	return s.ClientStream.Header()
+}
+
+func (s *fallbackClientStream) Trailer() metadata.MD {
+	if s.ClientStream == nil {
+		return nil
+	}
+
+	return s.ClientStream.Trailer()
+}
+
+func (s *fallbackClientStream) CloseSend() error {
+	if s.ClientStream == nil {
+		return nil
+	}
+
+	return s.ClientStream.CloseSend()
+}
+
+func (s *fallbackClientStream) Context() context.Context {
+	if s.ClientStream == nil {
+		return s.ctx
+	}
+
+	return s.ClientStream.Context()
+}
@@ -0,0 +1,331 @@
+package interceptor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/viquitorreis/my-grpc-go-client/internal/config"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// FaultInjector injects latency, errors and broken stream messages into calls for chaos testing.
+type FaultInjector struct {
+	methods map[string]methodFaults
+
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+type methodFaults struct {
+	config.MethodFaults
+
+	abortBeforeCode codes.Code
+	abortAfterCode  codes.Code
+	cutCode         codes.Code
+}
+
+// NewFaultInjector builds the injector from cfg. It fails for the production profile
+// or when fault injection is not explicitly enabled, so it can not be turned on by accident.
+func NewFaultInjector(cfg *config.Config) (*FaultInjector, error) {
+	if cfg.Profile == config.ProfileProduction {
+		return nil, fmt.Errorf("fault injection is not allowed with the %v profile", cfg.Profile)
+	}
+
+	if !cfg.FaultInjection.Enabled {
+		return nil, fmt.Errorf("fault injection is not enabled")
+	}
+
+	f := &FaultInjector{
+		methods: make(map[string]methodFaults, len(cfg.FaultInjection.Methods)),
+		rnd:     rand.New(rand.NewPCG(cfg.FaultInjection.Seed, cfg.FaultInjection.Seed)),
+	}
+
+	if cfg.FaultInjection.Seed == 0 {
+		f.rnd = rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+	}
+
+	for method, faults := range cfg.FaultInjection.Methods {
+		mf := methodFaults{
+			MethodFaults: faults,
+			cutCode:      codes.Unavailable,
+		}
+
+		var err error
+		if faults.AbortBefore != nil {
+			if mf.abortBeforeCode, err = ParseCode(faults.AbortBefore.Code); err != nil {
+				return nil, fmt.Errorf("fault injection for %v: %w", method, err)
+			}
+		}
+
+		if faults.AbortAfter != nil {
+			if mf.abortAfterCode, err = ParseCode(faults.AbortAfter.Code); err != nil {
+				return nil, fmt.Errorf("fault injection for %v: %w", method, err)
+			}
+		}
+
+		if faults.CutCode != "" {
+			if mf.cutCode, err = ParseCode(faults.CutCode); err != nil {
+				return nil, fmt.Errorf("fault injection for %v: %w", method, err)
+			}
+		}
+
+		f.methods[method] = mf
+	}
+
+	return f, nil
+}
+
+// ParseCode accepts the gRPC code names used in configs, e.g. UNAVAILABLE.
+func ParseCode(name string) (codes.Code, error) {
+	var code codes.Code
+	err := code.UnmarshalJSON([]byte(`"` + strings.ToUpper(name) + `"`))
+
+	return code, err
+}
+
+func (f *FaultInjector) faultsFor(method string) (methodFaults, bool) {
+	if mf, ok := f.methods[method]; ok {
+		return mf, true
+	}
+
+	mf, ok := f.methods["*"]
+
+	return mf, ok
+}
+
+// chance reports true with probability p.
+func (f *FaultInjector) chance(p float64) bool {
+	if p <= 0 {
+		return false
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.rnd.Float64() < p
+}
+
+func (f *FaultInjector) delay(ctx context.Context, method string, d *config.DelayFault) error {
+	if d == nil || !f.chance(d.Probability) {
+		return nil
+	}
+
+	wait := d.Min
+	if d.Max > d.Min {
+		f.mu.Lock()
+		wait += time.Duration(f.rnd.Int64N(int64(d.Max - d.Min)))
+		f.mu.Unlock()
+	}
+
+	log.Printf("[FAULT INJECTION] delaying %v by %v\n", method, wait)
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return status.FromContextError(ctx.Err()).Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (f *FaultInjector) abort(method string, a *config.AbortFault, code codes.Code) error {
+	if a == nil || !f.chance(a.Probability) {
+		return nil
+	}
+
+	msg := a.Message
+	if msg == "" {
+		msg = "injected fault"
+	}
+
+	log.Printf("[FAULT INJECTION] aborting %v with %v\n", method, code)
+
+	return status.Error(code, msg)
+}
+
+func (f *FaultInjector) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context, method string, req, reply any,
+		cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption,
+	) error {
+		mf, ok := f.faultsFor(method)
+		if !ok {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		if err := f.delay(ctx, method, mf.Delay); err != nil {
+			return err
+		}
+
+		if err := f.abort(method, mf.AbortBefore, mf.abortBeforeCode); err != nil {
+			return err
+		}
+
+		if err := invoker(ctx, method, req, reply, cc, opts...); err != nil {
+			return err
+		}
+
+		return f.abort(method, mf.AbortAfter, mf.abortAfterCode)
+	}
+}
+
+func (f *FaultInjector) StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(
+		ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn,
+		method string, streamer grpc.Streamer, opts ...grpc.CallOption,
+	) (grpc.ClientStream, error) {
+		mf, ok := f.faultsFor(method)
+		if !ok {
+			return streamer(ctx, desc, cc, method, opts...)
+		}
+
+		if err := f.delay(ctx, method, mf.Delay); err != nil {
+			return nil, err
+		}
+
+		if err := f.abort(method, mf.AbortBefore, mf.abortBeforeCode); err != nil {
+			return nil, err
+		}
+
+		ctx, cancel := context.WithCancel(ctx)
+
+		clientStream, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+
+		go func() {
+			<-clientStream.Context().Done()
+			cancel()
+		}()
+
+		return &faultInjectedStream{
+			ClientStream: clientStream,
+			injector:     f,
+			method:       method,
+			faults:       mf,
+			cancel:       cancel,
+		}, nil
+	}
+}
+
+type faultInjectedStream struct {
+	grpc.ClientStream
+
+	injector *FaultInjector
+	method   string
+	faults   methodFaults
+	cancel   context.CancelFunc
+	received int
+	// err is returned by every RecvMsg once the stream was cut by a fault.
+	err error
+}
+
+func (s *faultInjectedStream) RecvMsg(msg any) error {
+	if s.err != nil {
+		return s.err
+	}
+
+	if s.faults.CutAfterMessages > 0 && s.received >= s.faults.CutAfterMessages {
+		log.Printf("[FAULT INJECTION] cutting %v after %v messages\n", s.method, s.received)
+
+		s.cancel()
+		s.err = status.Errorf(s.faults.cutCode, "stream cut by injected fault after %d messages", s.received)
+
+		return s.err
+	}
+
+	for {
+		err := s.ClientStream.RecvMsg(msg)
+		if err == io.EOF {
+			if abortErr := s.injector.abort(s.method, s.faults.AbortAfter, s.faults.abortAfterCode); abortErr != nil {
+				s.err = abortErr
+				return abortErr
+			}
+		}
+
+		if err != nil {
+			s.cancel()
+			return err
+		}
+
+		if s.injector.chance(s.faults.DropMessage) {
+			log.Printf("[FAULT INJECTION] dropping message of %v\n", s.method)
+			continue
+		}
+
+		s.received++
+
+		if s.injector.chance(s.faults.CorruptMessage) {
+			log.Printf("[FAULT INJECTION] corrupting message of %v\n", s.method)
+			s.injector.corrupt(msg)
+		}
+
+		return nil
+	}
+}
+
+// corrupt garbles one populated scalar field of msg, picked at random.
+func (f *FaultInjector) corrupt(msg any) {
+	m, ok := msg.(proto.Message)
+	if !ok {
+		return
+	}
+
+	r := m.ProtoReflect()
+
+	var fields []protoreflect.FieldDescriptor
+	r.Range(func(fd protoreflect.FieldDescriptor, _ protoreflect.Value) bool {
+		if !fd.IsList() && !fd.IsMap() && fd.Kind() != protoreflect.MessageKind && fd.Kind() != protoreflect.GroupKind {
+			fields = append(fields, fd)
+		}
+		return true
+	})
+
+	if len(fields) == 0 {
+		return
+	}
+
+	f.mu.Lock()
+	fd := fields[f.rnd.IntN(len(fields))]
+	noise := f.rnd.Int64()
+	f.mu.Unlock()
+
+	v := r.Get(fd)
+
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		r.Set(fd, protoreflect.ValueOfString(v.String()+"\uFFFD"))
+	case protoreflect.BytesKind:
+		r.Set(fd, protoreflect.ValueOfBytes(append(v.Bytes(), 0xff)))
+	case protoreflect.BoolKind:
+		r.Set(fd, protoreflect.ValueOfBool(!v.Bool()))
+	case protoreflect.DoubleKind:
+		r.Set(fd, protoreflect.ValueOfFloat64(-v.Float()))
+	case protoreflect.FloatKind:
+		r.Set(fd, protoreflect.ValueOfFloat32(float32(-v.Float())))
+	case protoreflect.EnumKind:
+		r.Set(fd, protoreflect.ValueOfEnum(v.Enum()+1))
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		r.Set(fd, protoreflect.ValueOfInt32(int32(noise)))
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		r.Set(fd, protoreflect.ValueOfInt64(noise))
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		r.Set(fd, protoreflect.ValueOfUint32(uint32(noise)))
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		r.Set(fd, protoreflect.ValueOfUint64(uint64(noise)))
+	}
+}
@@ -0,0 +1,297 @@
+package interceptor
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/viquitorreis/my-grpc-go-client/internal/redact"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+type CallLoggerSettings struct {
+	// Logger defaults to slog.Default().
+	Logger *slog.Logger
+	// Level is the level of successful calls, Methods overrides it per full method name.
+	// Failed calls are logged at least at warn.
+	Level   slog.Level
+	Methods map[string]slog.Level
+	// SampleRates keeps this fraction of the successful calls of a method.
+	SampleRates map[string]float64
+	// Payloads adds the request and response of unary calls, the first request and the
+	// last response of streams, masked by Redactor (redact.Default() when nil).
+	Payloads bool
+	Redactor *redact.Redactor
+}
+
+// CallLogger writes one structured record per call once it finishes.
+type CallLogger struct {
+	settings CallLoggerSettings
+}
+
+func NewCallLogger(settings CallLoggerSettings) *CallLogger {
+	if settings.Logger == nil {
+		settings.Logger = slog.Default()
+	}
+
+	if settings.Redactor == nil {
+		settings.Redactor = redact.Default()
+	}
+
+	return &CallLogger{settings: settings}
+}
+
+type callRecord struct {
+	method   string
+	rpcType  string
+	peer     peer.Peer
+	start    time.Time
+	err      error
+	sent     int
+	received int
+	reqSize  int
+	resSize  int
+	req, res any
+}
+
+func rpcType(desc *grpc.StreamDesc) string {
+	switch {
+	case desc.ClientStreams && desc.ServerStreams:
+		return "bidi_stream"
+	case desc.ClientStreams:
+		return "client_stream"
+	case desc.ServerStreams:
+		return "server_stream"
+	}
+
+	return "unary"
+}
+
+func (l *CallLogger) log(ctx context.Context, r *callRecord) {
+	level, ok := l.settings.Methods[r.method]
+	if !ok {
+		level = l.settings.Level
+	}
+
+	code := status.Code(r.err)
+
+	if code == codes.OK {
+		if rate, ok := l.settings.SampleRates[r.method]; ok && rand.Float64() >= rate {
+			return
+		}
+	} else {
+		level = max(level, slog.LevelWarn)
+	}
+
+	if !l.settings.Logger.Enabled(ctx, level) {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("method", r.method),
+		slog.String("rpc_type", r.rpcType),
+		slog.Duration("duration", time.Since(r.start)),
+		slog.String("code", code.String()),
+		slog.Int("request_size", r.reqSize),
+		slog.Int("response_size", r.resSize),
+	}
+
+	if r.peer.Addr != nil {
+		attrs = append(attrs, slog.String("peer", r.peer.Addr.String()))
+	}
+
+	if r.rpcType != "unary" {
+		attrs = append(attrs, slog.Int("sent_messages", r.sent), slog.Int("received_messages", r.received))
+	}
+
+	if id := CorrelationID(ctx); id != "" {
+		attrs = append(attrs, slog.String("correlation_id", id))
+	}
+
+	if r.err != nil {
+		attrs = append(attrs, slog.String("error", status.Convert(r.err).Message()))
+	}
+
+	if l.settings.Payloads {
+		if req := l.payload(r.req); req != "" {
+			attrs = append(attrs, slog.String("request", req))
+		}
+
+		if res := l.payload(r.res); res != "" {
+			attrs = append(attrs, slog.String("response", res))
+		}
+	}
+
+	l.settings.Logger.LogAttrs(ctx, level, "grpc call", attrs...)
+}
+
+// payload renders the redacted msg as JSON, empty for anything but a protobuf message.
+func (l *CallLogger) payload(msg any) string {
+	m, ok := msg.(proto.Message)
+	if !ok {
+		return ""
+	}
+
+	b, err := protojson.Marshal(l.settings.Redactor.Redact(m))
+	if err != nil {
+		return ""
+	}
+
+	return string(b)
+}
+
+func messageSize(msg any) int {
+	if m, ok := msg.(proto.Message); ok {
+		return proto.Size(m)
+	}
+
+	return 0
+}
+
+func (l *CallLogger) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context, method string, req, reply any,
+		cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption,
+	) error {
+		r := &callRecord{
+			method:  method,
+			rpcType: "unary",
+			start:   time.Now(),
+			reqSize: messageSize(req),
+			req:     req,
+		}
+
+		r.err = invoker(ctx, method, req, reply, cc, append(opts, grpc.Peer(&r.peer))...)
+		if r.err == nil {
+			r.resSize = messageSize(reply)
+			r.res = reply
+		}
+
+		l.log(ctx, r)
+
+		return r.err
+	}
+}
+
+func (l *CallLogger) StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(
+		ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn,
+		method string, streamer grpc.Streamer, opts ...grpc.CallOption,
+	) (grpc.ClientStream, error) {
+		s := &loggedClientStream{
+			logger: l,
+			ctx:    ctx,
+			desc:   desc,
+			record: &callRecord{
+				method:  method,
+				rpcType: rpcType(desc),
+				start:   time.Now(),
+			},
+		}
+
+		clientStream, err := streamer(ctx, desc, cc, method, append(opts, grpc.Peer(&s.record.peer))...)
+		if err != nil {
+			s.finish(err)
+			return nil, err
+		}
+
+		s.ClientStream = clientStream
+
+		// streams abandoned by the caller never get their final status from RecvMsg
+		go func() {
+			<-clientStream.Context().Done()
+
+			if ctx.Err() != nil {
+				s.finish(nil)
+			}
+		}()
+
+		return s, nil
+	}
+}
+
+type loggedClientStream struct {
+	grpc.ClientStream
+
+	logger *CallLogger
+	ctx    context.Context
+	desc   *grpc.StreamDesc
+
+	mu     sync.Mutex
+	record *callRecord
+	done   sync.Once
+}
+
+func (s *loggedClientStream) SendMsg(msg any) error {
+	err := s.ClientStream.SendMsg(msg)
+	if err == nil {
+		s.mu.Lock()
+		s.record.sent++
+		s.record.reqSize += messageSize(msg)
+		if s.record.sent == 1 {
+			s.record.req = s.keep(msg)
+		}
+		s.mu.Unlock()
+	}
+
+	return err
+}
+
+func (s *loggedClientStream) RecvMsg(msg any) error {
+	err := s.ClientStream.RecvMsg(msg)
+	if err != nil {
+		if err == io.EOF {
+			s.finish(nil)
+		} else {
+			s.finish(err)
+		}
+
+		return err
+	}
+
+	s.mu.Lock()
+	s.record.received++
+	s.record.resSize += messageSize(msg)
+	s.record.res = s.keep(msg)
+	s.mu.Unlock()
+
+	// the single response of a unary-response stream ends it
+	if !s.desc.ServerStreams {
+		s.finish(nil)
+	}
+
+	return nil
+}
+
+// keep copies msg for the payloads, the caller may reuse it for the next message.
+func (s *loggedClientStream) keep(msg any) any {
+	m, ok := msg.(proto.Message)
+	if !ok || !s.logger.settings.Payloads {
+		return nil
+	}
+
+	return proto.Clone(m)
+}
+
+func (s *loggedClientStream) finish(err error) {
+	s.done.Do(func() {
+		// an abandoned stream ends with the error of the caller context
+		if err == nil && s.ctx.Err() != nil {
+			err = status.FromContextError(s.ctx.Err()).Err()
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		s.record.err = err
+		s.logger.log(s.ctx, s.record)
+	})
+}
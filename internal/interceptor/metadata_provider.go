@@ -0,0 +1,144 @@
+package interceptor
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/google/uuid"
+	"github.com/viquitorreis/my-grpc-go-client/internal/config"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	RequestIDMetadataKey     = "x-request-id"
+	ClientVersionMetadataKey = "x-client-version"
+	ClientHostMetadataKey    = "x-client-host"
+	TenantMetadataKey        = "x-tenant-id"
+)
+
+// MetadataProvider returns the metadata to attach to a call when it starts.
+type MetadataProvider interface {
+	Metadata(ctx context.Context, method string) (metadata.MD, error)
+}
+
+type MetadataProviderFunc func(ctx context.Context, method string) (metadata.MD, error)
+
+func (f MetadataProviderFunc) Metadata(ctx context.Context, method string) (metadata.MD, error) {
+	return f(ctx, method)
+}
+
+// RequestIDProvider sends a new UUID under key unless the outgoing context already carries one.
+func RequestIDProvider(key string) MetadataProvider {
+	return MetadataProviderFunc(func(ctx context.Context, method string) (metadata.MD, error) {
+		if md, ok := metadata.FromOutgoingContext(ctx); ok && len(md.Get(key)) > 0 {
+			return nil, nil
+		}
+
+		return metadata.Pairs(key, uuid.New().String()), nil
+	})
+}
+
+func ClientVersionProvider(version string) MetadataProvider {
+	return StaticProvider(map[string]string{ClientVersionMetadataKey: version})
+}
+
+// HostProvider sends the host name of the machine running the client.
+func HostProvider() MetadataProvider {
+	host, err := os.Hostname()
+
+	return MetadataProviderFunc(func(ctx context.Context, method string) (metadata.MD, error) {
+		if err != nil {
+			return nil, fmt.Errorf("failed to get host name: %w", err)
+		}
+
+		return metadata.Pairs(ClientHostMetadataKey, host), nil
+	})
+}
+
+func TenantProvider(tenant string) MetadataProvider {
+	return StaticProvider(map[string]string{TenantMetadataKey: tenant})
+}
+
+func StaticProvider(pairs map[string]string) MetadataProvider {
+	md := metadata.New(pairs)
+
+	return MetadataProviderFunc(func(ctx context.Context, method string) (metadata.MD, error) {
+		return md, nil
+	})
+}
+
+// NewMetadataProviders builds the built-in providers enabled in cfg.
+func NewMetadataProviders(cfg config.Metadata, clientVersion string) []MetadataProvider {
+	var providers []MetadataProvider
+
+	if cfg.RequestID {
+		providers = append(providers, RequestIDProvider(RequestIDMetadataKey))
+	}
+
+	if cfg.ClientVersion {
+		providers = append(providers, ClientVersionProvider(clientVersion))
+	}
+
+	if cfg.Host {
+		providers = append(providers, HostProvider())
+	}
+
+	if cfg.Tenant != "" {
+		providers = append(providers, TenantProvider(cfg.Tenant))
+	}
+
+	if len(cfg.Static) > 0 {
+		providers = append(providers, StaticProvider(cfg.Static))
+	}
+
+	return providers
+}
+
+func withProvidedMetadata(ctx context.Context, method string, providers []MetadataProvider) (context.Context, error) {
+	for _, p := range providers {
+		md, err := p.Metadata(ctx, method)
+		if err != nil {
+			return nil, err
+		}
+
+		for k, values := range md {
+			for _, v := range values {
+				ctx = metadata.AppendToOutgoingContext(ctx, k, v)
+			}
+		}
+	}
+
+	return ctx, nil
+}
+
+// MetadataUnaryClientInterceptor attaches the metadata of every provider to each call.
+func MetadataUnaryClientInterceptor(providers ...MetadataProvider) grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context, method string, req, reply any,
+		cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption,
+	) error {
+		ctx, err := withProvidedMetadata(ctx, method, providers)
+		if err != nil {
+			return err
+		}
+
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// MetadataStreamClientInterceptor attaches the metadata of every provider when each stream opens.
+func MetadataStreamClientInterceptor(providers ...MetadataProvider) grpc.StreamClientInterceptor {
+	return func(
+		ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn,
+		method string, streamer grpc.Streamer, opts ...grpc.CallOption,
+	) (grpc.ClientStream, error) {
+		ctx, err := withProvidedMetadata(ctx, method, providers)
+		if err != nil {
+			return nil, err
+		}
+
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+}
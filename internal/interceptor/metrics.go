@@ -0,0 +1,224 @@
+package interceptor
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sony/gobreaker"
+	"github.com/viquitorreis/my-grpc-go-client/internal/metrics"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/status"
+)
+
+// Metrics counts the calls of the client in a metrics registry.
+type Metrics struct {
+	reg *metrics.Registry
+
+	started  *metrics.Counter
+	handled  *metrics.Counter
+	latency  *metrics.Histogram
+	inFlight *metrics.Gauge
+	sent     *metrics.Counter
+	received *metrics.Counter
+
+	retries            *metrics.Counter
+	breakerState       *metrics.Gauge
+	breakerTransitions *metrics.Counter
+	connState          *metrics.Gauge
+	connTransitions    *metrics.Counter
+
+	concurrencyLimit    *metrics.Gauge
+	concurrencyInFlight *metrics.Gauge
+}
+
+func NewMetrics(reg *metrics.Registry) *Metrics {
+	return &Metrics{
+		reg: reg,
+		started: reg.NewCounter("grpc_client_started_total",
+			"Calls started by the client.", "grpc_type", "grpc_service", "grpc_method"),
+		handled: reg.NewCounter("grpc_client_handled_total",
+			"Calls finished by the client, by status code.", "grpc_type", "grpc_service", "grpc_method", "grpc_code"),
+		latency: reg.NewHistogram("grpc_client_handling_seconds",
+			"Duration of the calls until their status is known.", metrics.DefaultBuckets, "grpc_type", "grpc_service", "grpc_method"),
+		inFlight: reg.NewGauge("grpc_client_in_flight",
+			"Calls started and not finished yet.", "grpc_type", "grpc_service", "grpc_method"),
+		sent: reg.NewCounter("grpc_client_msg_sent_total",
+			"Stream messages sent by the client.", "grpc_type", "grpc_service", "grpc_method"),
+		received: reg.NewCounter("grpc_client_msg_received_total",
+			"Stream messages received by the client.", "grpc_type", "grpc_service", "grpc_method"),
+		retries: reg.NewCounter("grpc_client_retries_total",
+			"Retry attempts, the first attempt of a call aside.", "grpc_service", "grpc_method"),
+		breakerState: reg.NewGauge("grpc_client_circuit_breaker_state",
+			"State of a circuit breaker: 0 closed, 1 half-open, 2 open.", "name"),
+		breakerTransitions: reg.NewCounter("grpc_client_circuit_breaker_transitions_total",
+			"State changes of a circuit breaker.", "name", "from", "to"),
+		connState: reg.NewGauge("grpc_client_connection_state",
+			"Connectivity state of a connection: 0 idle, 1 connecting, 2 ready, 3 transient failure, 4 shutdown.", "target"),
+		connTransitions: reg.NewCounter("grpc_client_connection_transitions_total",
+			"Connectivity state changes of a connection.", "target", "from", "to"),
+		concurrencyLimit: reg.NewGauge("grpc_client_concurrency_limit",
+			"In-flight limit of a method set by the adaptive concurrency limiter.", "grpc_service", "grpc_method"),
+		concurrencyInFlight: reg.NewGauge("grpc_client_concurrency_in_flight",
+			"Calls holding a slot of the adaptive concurrency limiter.", "grpc_service", "grpc_method"),
+	}
+}
+
+// splitMethod turns /package.Service/Method into its service and method names.
+func splitMethod(fullMethod string) (string, string) {
+	service, method, ok := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	if !ok {
+		return "unknown", fullMethod
+	}
+
+	return service, method
+}
+
+// OnRetry has the signature of RetrySettings.OnRetry.
+func (m *Metrics) OnRetry(fullMethod string, attempt int, err error) {
+	service, method := splitMethod(fullMethod)
+	m.retries.Inc(service, method)
+}
+
+// OnStateChange has the signature of gobreaker.Settings.OnStateChange.
+func (m *Metrics) OnStateChange(name string, from, to gobreaker.State) {
+	m.breakerState.Set(float64(to), name)
+	m.breakerTransitions.Inc(name, from.String(), to.String())
+}
+
+// OnConnectivityChange records a connectivity transition of the connection to target.
+func (m *Metrics) OnConnectivityChange(target string, from, to connectivity.State) {
+	m.connState.Set(float64(to), target)
+	m.connTransitions.Inc(target, from.String(), to.String())
+}
+
+// ConcurrencyLimiter publishes the limits and in-flight calls of l every time the metrics are collected.
+func (m *Metrics) ConcurrencyLimiter(l *ConcurrencyLimiter) {
+	m.reg.OnCollect(func() {
+		for fullMethod, limit := range l.Limits() {
+			service, method := splitMethod(fullMethod)
+			m.concurrencyLimit.Set(float64(limit), service, method)
+			m.concurrencyInFlight.Set(float64(l.InFlight(fullMethod)), service, method)
+		}
+	})
+}
+
+func (m *Metrics) start(rpcType, fullMethod string) []string {
+	service, method := splitMethod(fullMethod)
+	labels := []string{rpcType, service, method}
+
+	m.started.Inc(labels...)
+	m.inFlight.Inc(labels...)
+
+	return labels
+}
+
+func (m *Metrics) finish(labels []string, start time.Time, err error) {
+	m.inFlight.Dec(labels...)
+	m.latency.Observe(time.Since(start).Seconds(), labels...)
+	m.handled.Inc(append(labels, status.Code(err).String())...)
+}
+
+func (m *Metrics) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context, method string, req, reply any,
+		cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption,
+	) error {
+		start := time.Now()
+		labels := m.start("unary", method)
+
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		m.finish(labels, start, err)
+
+		return err
+	}
+}
+
+func (m *Metrics) StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(
+		ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn,
+		method string, streamer grpc.Streamer, opts ...grpc.CallOption,
+	) (grpc.ClientStream, error) {
+		s := &metricsClientStream{
+			metrics: m,
+			ctx:     ctx,
+			desc:    desc,
+			start:   time.Now(),
+			labels:  m.start(rpcType(desc), method),
+		}
+
+		clientStream, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			s.finish(err)
+			return nil, err
+		}
+
+		s.ClientStream = clientStream
+
+		// streams abandoned by the caller never get their final status from RecvMsg
+		go func() {
+			<-clientStream.Context().Done()
+
+			if ctx.Err() != nil {
+				s.finish(nil)
+			}
+		}()
+
+		return s, nil
+	}
+}
+
+type metricsClientStream struct {
+	grpc.ClientStream
+
+	metrics *Metrics
+	ctx     context.Context
+	desc    *grpc.StreamDesc
+	start   time.Time
+	labels  []string
+	done    sync.Once
+}
+
+func (s *metricsClientStream) SendMsg(msg any) error {
+	err := s.ClientStream.SendMsg(msg)
+	if err == nil {
+		s.metrics.sent.Inc(s.labels...)
+	}
+
+	return err
+}
+
+func (s *metricsClientStream) RecvMsg(msg any) error {
+	err := s.ClientStream.RecvMsg(msg)
+	if err != nil {
+		if err == io.EOF {
+			s.finish(nil)
+		} else {
+			s.finish(err)
+		}
+
+		return err
+	}
+
+	s.metrics.received.Inc(s.labels...)
+
+	// the single response of a unary-response stream ends it
+	if !s.desc.ServerStreams {
+		s.finish(nil)
+	}
+
+	return nil
+}
+
+func (s *metricsClientStream) finish(err error) {
+	s.done.Do(func() {
+		if err == nil && s.ctx.Err() != nil {
+			err = status.FromContextError(s.ctx.Err()).Err()
+		}
+
+		s.metrics.finish(s.labels, s.start, err)
+	})
+}
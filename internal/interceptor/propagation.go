@@ -0,0 +1,110 @@
+package interceptor
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// PropagationSettings is the allow-list of incoming metadata copied onto outgoing calls.
+type PropagationSettings struct {
+	// Keys are exact metadata keys, Prefixes match every key starting with them.
+	Keys     []string
+	Prefixes []string
+	// RequestIDKey is the key carrying the correlation ID, RequestIDMetadataKey by default.
+	RequestIDKey string
+}
+
+type correlationIDKey struct{}
+
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationIDKey{}, id)
+}
+
+// CorrelationID returns the request ID of the call, empty outside of Propagate.
+func CorrelationID(ctx context.Context) string {
+	id, _ := ctx.Value(correlationIDKey{}).(string)
+	return id
+}
+
+// Propagate copies the allow-listed metadata of the incoming call in ctx, if any, onto the
+// outgoing context, and makes sure it carries a request ID. The request ID is also stored
+// as the correlation ID of the returned context.
+func Propagate(ctx context.Context, settings PropagationSettings) context.Context {
+	requestIDKey := settings.RequestIDKey
+	if requestIDKey == "" {
+		requestIDKey = RequestIDMetadataKey
+	}
+
+	outgoing, _ := metadata.FromOutgoingContext(ctx)
+
+	if incoming, ok := metadata.FromIncomingContext(ctx); ok {
+		for k, values := range incoming {
+			// keys set by the caller of the client win over the incoming ones
+			if !settings.allows(k) || len(outgoing.Get(k)) > 0 {
+				continue
+			}
+
+			for _, v := range values {
+				ctx = metadata.AppendToOutgoingContext(ctx, k, v)
+			}
+		}
+	}
+
+	outgoing, _ = metadata.FromOutgoingContext(ctx)
+
+	id := CorrelationID(ctx)
+	if ids := outgoing.Get(requestIDKey); len(ids) > 0 {
+		id = ids[0]
+	} else {
+		if id == "" {
+			id = uuid.New().String()
+		}
+
+		ctx = metadata.AppendToOutgoingContext(ctx, requestIDKey, id)
+	}
+
+	return WithCorrelationID(ctx, id)
+}
+
+func (s PropagationSettings) allows(key string) bool {
+	// pseudo headers and the keys owned by grpc are never forwarded
+	if strings.HasPrefix(key, ":") || strings.HasPrefix(key, "grpc-") || key == "content-type" || key == "user-agent" {
+		return false
+	}
+
+	for _, k := range s.Keys {
+		if strings.EqualFold(k, key) {
+			return true
+		}
+	}
+
+	for _, p := range s.Prefixes {
+		if strings.HasPrefix(key, strings.ToLower(p)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func PropagationUnaryClientInterceptor(settings PropagationSettings) grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context, method string, req, reply any,
+		cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption,
+	) error {
+		return invoker(Propagate(ctx, settings), method, req, reply, cc, opts...)
+	}
+}
+
+func PropagationStreamClientInterceptor(settings PropagationSettings) grpc.StreamClientInterceptor {
+	return func(
+		ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn,
+		method string, streamer grpc.Streamer, opts ...grpc.CallOption,
+	) (grpc.ClientStream, error) {
+		return streamer(Propagate(ctx, settings), desc, cc, method, opts...)
+	}
+}
@@ -0,0 +1,276 @@
+package interceptor
+
+import (
+	"context"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/viquitorreis/my-grpc-go-client/internal/capture"
+	"github.com/viquitorreis/my-grpc-go-client/internal/redact"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+type RecorderSettings struct {
+	// Methods are full method names or patterns for NewMethodMatcher, every method is recorded when empty.
+	Methods []string
+	// MaskMetadata are the metadata keys whose values are replaced before writing.
+	MaskMetadata []string
+	// Redactor masks the message bodies, redact.Default() when nil.
+	Redactor *redact.Redactor
+}
+
+// Recorder writes every call and stream message to a capture, see package capture.
+type Recorder struct {
+	w        *capture.Writer
+	settings RecorderSettings
+	methods  *MethodMatcher
+}
+
+func NewRecorder(w *capture.Writer, settings RecorderSettings) *Recorder {
+	if settings.Redactor == nil {
+		settings.Redactor = redact.Default()
+	}
+
+	return &Recorder{w: w, settings: settings, methods: NewMethodMatcher(settings.Methods)}
+}
+
+func (r *Recorder) records(method string) bool {
+	return r.methods.Match(method)
+}
+
+func (r *Recorder) write(e *capture.Entry) {
+	e.Time = time.Now()
+
+	if err := r.w.Write(e); err != nil {
+		log.Println("Failed to write capture entry: ", err)
+	}
+}
+
+// setBody writes the masked msg to e, along with the names of the masked fields.
+func (r *Recorder) setBody(e *capture.Entry, msg any) {
+	m, ok := msg.(proto.Message)
+	if !ok {
+		return
+	}
+
+	b, err := protojson.Marshal(r.settings.Redactor.Redact(m))
+	if err != nil {
+		log.Println("Failed to encode capture body: ", err)
+		return
+	}
+
+	e.Body = b
+	e.Redacted = r.settings.Redactor.Redacted(m)
+}
+
+func (r *Recorder) mask(md metadata.MD) metadata.MD {
+	if md.Len() == 0 {
+		return nil
+	}
+
+	md = md.Copy()
+	for _, k := range r.settings.MaskMetadata {
+		k = strings.ToLower(k)
+		if values, ok := md[k]; ok {
+			masked := make([]string, len(values))
+			for i := range masked {
+				masked[i] = "***"
+			}
+
+			md[k] = masked
+		}
+	}
+
+	return md
+}
+
+func captureStatus(err error) *capture.Status {
+	st := status.Convert(err)
+
+	return &capture.Status{
+		Code:    st.Code().String(),
+		Message: st.Message(),
+	}
+}
+
+func (r *Recorder) start(ctx context.Context, method, rpcType string) *capture.Entry {
+	md, _ := metadata.FromOutgoingContext(ctx)
+
+	e := &capture.Entry{
+		CallID:    uuid.New().String(),
+		Method:    method,
+		Type:      rpcType,
+		Direction: capture.DirectionStart,
+		Metadata:  r.mask(md),
+	}
+	r.write(e)
+
+	return e
+}
+
+// next returns a new entry of the same call as e.
+func next(e *capture.Entry, direction capture.Direction, seq int) *capture.Entry {
+	return &capture.Entry{
+		CallID:    e.CallID,
+		Method:    e.Method,
+		Type:      e.Type,
+		Direction: direction,
+		Seq:       seq,
+	}
+}
+
+func (r *Recorder) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context, method string, req, reply any,
+		cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption,
+	) error {
+		if !r.records(method) {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		start := r.start(ctx, method, "unary")
+
+		send := next(start, capture.DirectionSend, 0)
+		r.setBody(send, req)
+		r.write(send)
+
+		var header, trailer metadata.MD
+		err := invoker(ctx, method, req, reply, cc, append(opts, grpc.Header(&header), grpc.Trailer(&trailer))...)
+
+		if err == nil {
+			recv := next(start, capture.DirectionRecv, 0)
+			r.setBody(recv, reply)
+			r.write(recv)
+		}
+
+		end := next(start, capture.DirectionEnd, 0)
+		end.Header = r.mask(header)
+		end.Trailer = r.mask(trailer)
+		end.Status = captureStatus(err)
+		r.write(end)
+
+		return err
+	}
+}
+
+func (r *Recorder) StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(
+		ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn,
+		method string, streamer grpc.Streamer, opts ...grpc.CallOption,
+	) (grpc.ClientStream, error) {
+		if !r.records(method) {
+			return streamer(ctx, desc, cc, method, opts...)
+		}
+
+		s := &recordedClientStream{
+			recorder: r,
+			ctx:      ctx,
+			desc:     desc,
+			start:    r.start(ctx, method, rpcType(desc)),
+		}
+
+		clientStream, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			s.finish(err)
+			return nil, err
+		}
+
+		s.ClientStream = clientStream
+
+		// streams abandoned by the caller never get their final status from RecvMsg
+		go func() {
+			<-clientStream.Context().Done()
+
+			if ctx.Err() != nil {
+				s.finish(nil)
+			}
+		}()
+
+		return s, nil
+	}
+}
+
+type recordedClientStream struct {
+	grpc.ClientStream
+
+	recorder *Recorder
+	ctx      context.Context
+	desc     *grpc.StreamDesc
+	start    *capture.Entry
+
+	mu       sync.Mutex
+	sent     int
+	received int
+	done     sync.Once
+}
+
+func (s *recordedClientStream) SendMsg(msg any) error {
+	err := s.ClientStream.SendMsg(msg)
+	if err == nil {
+		s.mu.Lock()
+		e := next(s.start, capture.DirectionSend, s.sent)
+		s.sent++
+		s.mu.Unlock()
+
+		s.recorder.setBody(e, msg)
+		s.recorder.write(e)
+	}
+
+	return err
+}
+
+func (s *recordedClientStream) RecvMsg(msg any) error {
+	err := s.ClientStream.RecvMsg(msg)
+	if err != nil {
+		if err == io.EOF {
+			s.finish(nil)
+		} else {
+			s.finish(err)
+		}
+
+		return err
+	}
+
+	s.mu.Lock()
+	e := next(s.start, capture.DirectionRecv, s.received)
+	s.received++
+	s.mu.Unlock()
+
+	s.recorder.setBody(e, msg)
+	s.recorder.write(e)
+
+	// the single response of a unary-response stream ends it
+	if !s.desc.ServerStreams {
+		s.finish(nil)
+	}
+
+	return nil
+}
+
+func (s *recordedClientStream) finish(err error) {
+	s.done.Do(func() {
+		if err == nil && s.ctx.Err() != nil {
+			err = status.FromContextError(s.ctx.Err()).Err()
+		}
+
+		end := next(s.start, capture.DirectionEnd, 0)
+		end.Status = captureStatus(err)
+
+		// the stream is nil when it failed to open
+		if s.ClientStream != nil {
+			header, _ := s.ClientStream.Header()
+			end.Header = s.recorder.mask(header)
+			end.Trailer = s.recorder.mask(s.ClientStream.Trailer())
+		}
+
+		s.recorder.write(end)
+	})
+}
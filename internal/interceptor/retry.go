@@ -0,0 +1,226 @@
+package interceptor
+
+import (
+	"context"
+	"io"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/viquitorreis/my-grpc-go-client/internal/tracing"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// RetryAttemptMetadataKey is sent on every retried call with the attempt number.
+const RetryAttemptMetadataKey = "x-retry-attempt"
+
+type retryAttemptKey struct{}
+
+// RetryAttempt returns the attempt number of the call running with ctx, 0 for the first try.
+func RetryAttempt(ctx context.Context) int {
+	attempt, _ := ctx.Value(retryAttemptKey{}).(int)
+	return attempt
+}
+
+type RetrySettings struct {
+	// Max is the number of retries after the first attempt.
+	Max   int
+	Codes []codes.Code
+	// Backoff returns how long to wait before the given retry (starting at 1).
+	Backoff func(attempt int) time.Duration
+	// OnRetry is called before every retry with the error of the previous attempt.
+	OnRetry func(method string, attempt int, err error)
+}
+
+func BackoffLinear(wait time.Duration) func(attempt int) time.Duration {
+	return func(int) time.Duration {
+		return wait
+	}
+}
+
+func BackoffExponential(base time.Duration) func(attempt int) time.Duration {
+	return func(attempt int) time.Duration {
+		return base * time.Duration(1<<(attempt-1))
+	}
+}
+
+func (s RetrySettings) retryable(err error) bool {
+	return slices.Contains(s.Codes, status.Code(err))
+}
+
+// wait sleeps before the given retry, returning early with the context error.
+func (s RetrySettings) wait(ctx context.Context, method string, attempt int, lastErr error) error {
+	if s.OnRetry != nil {
+		s.OnRetry(method, attempt, lastErr)
+	}
+
+	if span := tracing.SpanFromContext(ctx); span != nil {
+		span.SetAttribute("rpc.retry.attempt", attempt)
+		span.AddEvent("retry", "rpc.retry.attempt", attempt, "error", status.Convert(lastErr).Message())
+	}
+
+	if s.Backoff == nil {
+		return nil
+	}
+
+	timer := time.NewTimer(s.Backoff(attempt))
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return status.FromContextError(ctx.Err()).Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func withRetryAttempt(ctx context.Context, attempt int) context.Context {
+	ctx = context.WithValue(ctx, retryAttemptKey{}, attempt)
+	if attempt == 0 {
+		return ctx
+	}
+
+	return metadata.AppendToOutgoingContext(ctx, RetryAttemptMetadataKey, strconv.Itoa(attempt))
+}
+
+func RetryUnaryClientInterceptor(settings RetrySettings) grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context, method string, req, reply any,
+		cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption,
+	) error {
+		var err error
+
+		for attempt := 0; attempt <= settings.Max; attempt++ {
+			if attempt > 0 {
+				if waitErr := settings.wait(ctx, method, attempt, err); waitErr != nil {
+					return err
+				}
+			}
+
+			err = invoker(withRetryAttempt(ctx, attempt), method, req, reply, cc, opts...)
+			if err == nil || !settings.retryable(err) {
+				return err
+			}
+		}
+
+		return err
+	}
+}
+
+// RetryStreamClientInterceptor retries stream creation, and for server streams it also
+// retries when the stream fails before the first response message arrives.
+func RetryStreamClientInterceptor(settings RetrySettings) grpc.StreamClientInterceptor {
+	return func(
+		ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn,
+		method string, streamer grpc.Streamer, opts ...grpc.CallOption,
+	) (grpc.ClientStream, error) {
+		var (
+			clientStream grpc.ClientStream
+			err          error
+			attempt      int
+		)
+
+		for ; attempt <= settings.Max; attempt++ {
+			if attempt > 0 {
+				if waitErr := settings.wait(ctx, method, attempt, err); waitErr != nil {
+					return nil, err
+				}
+			}
+
+			clientStream, err = streamer(withRetryAttempt(ctx, attempt), desc, cc, method, opts...)
+			if err == nil || !settings.retryable(err) {
+				break
+			}
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if desc.ClientStreams {
+			return clientStream, nil
+		}
+
+		return &retryClientStream{
+			ClientStream: clientStream,
+			settings:     settings,
+			ctx:          ctx,
+			desc:         desc,
+			cc:           cc,
+			method:       method,
+			streamer:     streamer,
+			opts:         opts,
+			attempt:      min(attempt, settings.Max),
+		}, nil
+	}
+}
+
+// retryClientStream replays the single request of a server stream on a new stream
+// when the previous one failed before returning anything.
+type retryClientStream struct {
+	grpc.ClientStream
+
+	settings RetrySettings
+	ctx      context.Context
+	desc     *grpc.StreamDesc
+	cc       *grpc.ClientConn
+	method   string
+	streamer grpc.Streamer
+	opts     []grpc.CallOption
+
+	attempt    int
+	request    any
+	closedSend bool
+	received   bool
+}
+
+func (s *retryClientStream) SendMsg(msg any) error {
+	s.request = msg
+	return s.ClientStream.SendMsg(msg)
+}
+
+func (s *retryClientStream) CloseSend() error {
+	s.closedSend = true
+	return s.ClientStream.CloseSend()
+}
+
+func (s *retryClientStream) RecvMsg(msg any) error {
+	for {
+		err := s.ClientStream.RecvMsg(msg)
+		if err == nil {
+			s.received = true
+			return nil
+		}
+
+		if s.received || err == io.EOF || !s.settings.retryable(err) || s.attempt >= s.settings.Max {
+			return err
+		}
+
+		s.attempt++
+		if waitErr := s.settings.wait(s.ctx, s.method, s.attempt, err); waitErr != nil {
+			return err
+		}
+
+		clientStream, streamErr := s.streamer(withRetryAttempt(s.ctx, s.attempt), s.desc, s.cc, s.method, s.opts...)
+		if streamErr != nil {
+			return streamErr
+		}
+
+		if s.request != nil {
+			if sendErr := clientStream.SendMsg(s.request); sendErr != nil {
+				return sendErr
+			}
+		}
+
+		if s.closedSend {
+			if closeErr := clientStream.CloseSend(); closeErr != nil {
+				return closeErr
+			}
+		}
+
+		s.ClientStream = clientStream
+	}
+}
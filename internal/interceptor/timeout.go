@@ -0,0 +1,286 @@
+package interceptor
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var errStreamIdle = errors.New("stream idle timeout")
+
+// MethodTimeout is the timeout applied to one method. When Idle is set on a stream
+// it replaces Timeout: the stream lives as long as messages keep flowing.
+type MethodTimeout struct {
+	Timeout time.Duration
+	Idle    time.Duration
+}
+
+// DeadlineUsage is reported once per call when it finishes.
+type DeadlineUsage struct {
+	Method string
+	// Budget is the time the call was allowed to take, or the idle limit for idle-based streams.
+	Budget time.Duration
+	// Used is the call duration, or the longest gap between messages for idle-based streams.
+	Used time.Duration
+	Idle bool
+	Err  error
+}
+
+// Ratio is the fraction of the budget used by the call, zero when there was no budget.
+func (u DeadlineUsage) Ratio() float64 {
+	if u.Budget <= 0 {
+		return 0
+	}
+
+	return float64(u.Used) / float64(u.Budget)
+}
+
+type TimeoutPolicySettings struct {
+	Unary   MethodTimeout
+	Stream  MethodTimeout
+	Methods map[string]MethodTimeout
+	// OnDeadlineUsage is called when every call finishes.
+	OnDeadlineUsage func(DeadlineUsage)
+}
+
+// TimeoutPolicy applies per-method timeouts to calls. A caller deadline that is
+// stricter than the policy is always kept as is.
+type TimeoutPolicy struct {
+	settings TimeoutPolicySettings
+}
+
+func NewTimeoutPolicy(settings TimeoutPolicySettings) *TimeoutPolicy {
+	return &TimeoutPolicy{settings: settings}
+}
+
+func (p *TimeoutPolicy) timeoutFor(method string, stream bool) MethodTimeout {
+	if t, ok := p.settings.Methods[method]; ok {
+		return t
+	}
+
+	if stream {
+		return p.settings.Stream
+	}
+
+	return p.settings.Unary
+}
+
+func (p *TimeoutPolicy) report(usage DeadlineUsage) {
+	if p.settings.OnDeadlineUsage != nil {
+		p.settings.OnDeadlineUsage(usage)
+	}
+}
+
+// budget returns how long a call starting at start may take, and whether a new
+// deadline has to be set to enforce it (false when the caller deadline is already stricter).
+func budget(ctx context.Context, start time.Time, timeout time.Duration) (time.Duration, bool) {
+	if deadline, ok := ctx.Deadline(); ok {
+		remaining := deadline.Sub(start)
+		if timeout <= 0 || remaining <= timeout {
+			return remaining, false
+		}
+	}
+
+	return timeout, timeout > 0
+}
+
+func (p *TimeoutPolicy) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context, method string, req, reply any,
+		cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption,
+	) error {
+		start := time.Now()
+
+		b, wrap := budget(ctx, start, p.timeoutFor(method, false).Timeout)
+		if wrap {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, b)
+			defer cancel()
+		}
+
+		err := invoker(ctx, method, req, reply, cc, opts...)
+
+		p.report(DeadlineUsage{
+			Method: method,
+			Budget: b,
+			Used:   time.Since(start),
+			Err:    err,
+		})
+
+		return err
+	}
+}
+
+func (p *TimeoutPolicy) StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(
+		ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn,
+		method string, streamer grpc.Streamer, opts ...grpc.CallOption,
+	) (grpc.ClientStream, error) {
+		t := p.timeoutFor(method, true)
+		start := time.Now()
+
+		ctx, cancel := context.WithCancelCause(ctx)
+
+		s := &timeoutClientStream{
+			policy:       p,
+			method:       method,
+			start:        start,
+			lastActivity: start,
+			cancel:       func() { cancel(nil) },
+		}
+
+		if t.Idle > 0 {
+			s.idle = t.Idle
+			s.budget = t.Idle
+			s.timer = time.AfterFunc(t.Idle, func() { cancel(errStreamIdle) })
+		} else {
+			var wrap bool
+			s.budget, wrap = budget(ctx, start, t.Timeout)
+
+			if wrap {
+				var cancelTimeout context.CancelFunc
+				ctx, cancelTimeout = context.WithTimeout(ctx, s.budget)
+				s.cancel = func() {
+					cancelTimeout()
+					cancel(nil)
+				}
+			}
+		}
+
+		s.ctx = ctx
+
+		clientStream, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			s.finish(err)
+			return nil, s.idleError(err)
+		}
+
+		s.ClientStream = clientStream
+
+		// the stream context is done once the stream finishes for any reason, which is when
+		// the timers can be released. Streams that ended on their own get their status from
+		// RecvMsg, the others are finished here, idleError tells an idle stream by the cause.
+		go func() {
+			<-clientStream.Context().Done()
+
+			if ctx.Err() != nil {
+				s.finish(s.idleError(status.FromContextError(ctx.Err()).Err()))
+				return
+			}
+
+			s.release()
+		}()
+
+		return s, nil
+	}
+}
+
+type timeoutClientStream struct {
+	grpc.ClientStream
+
+	policy *TimeoutPolicy
+	method string
+	ctx    context.Context
+	cancel func()
+	start  time.Time
+	budget time.Duration
+
+	idle  time.Duration
+	timer *time.Timer
+
+	mu           sync.Mutex
+	lastActivity time.Time
+	maxGap       time.Duration
+
+	done sync.Once
+}
+
+// touch pushes the idle deadline forward after a message went through.
+func (s *timeoutClientStream) touch() {
+	if s.timer == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.maxGap = max(s.maxGap, now.Sub(s.lastActivity))
+	s.lastActivity = now
+
+	s.timer.Reset(s.idle)
+}
+
+func (s *timeoutClientStream) idleError(err error) error {
+	if errors.Is(context.Cause(s.ctx), errStreamIdle) {
+		return status.Errorf(codes.DeadlineExceeded, "stream %v idle for more than %v", s.method, s.idle)
+	}
+
+	return err
+}
+
+func (s *timeoutClientStream) SendMsg(msg any) error {
+	if err := s.ClientStream.SendMsg(msg); err != nil {
+		return s.idleError(err)
+	}
+
+	s.touch()
+
+	return nil
+}
+
+func (s *timeoutClientStream) RecvMsg(msg any) error {
+	if err := s.ClientStream.RecvMsg(msg); err != nil {
+		err = s.idleError(err)
+
+		if err == io.EOF {
+			s.finish(nil)
+		} else {
+			s.finish(err)
+		}
+
+		return err
+	}
+
+	s.touch()
+
+	return nil
+}
+
+// release stops the timers of the stream, finish still reports it.
+func (s *timeoutClientStream) release() {
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+
+	s.cancel()
+}
+
+func (s *timeoutClientStream) finish(err error) {
+	s.done.Do(func() {
+		usage := DeadlineUsage{
+			Method: s.method,
+			Budget: s.budget,
+			Used:   time.Since(s.start),
+			Err:    err,
+		}
+
+		if s.timer != nil {
+			s.timer.Stop()
+
+			s.mu.Lock()
+			usage.Idle = true
+			usage.Used = max(s.maxGap, time.Since(s.lastActivity))
+			s.mu.Unlock()
+		}
+
+		s.cancel()
+		s.policy.report(usage)
+	})
+}
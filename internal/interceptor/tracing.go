@@ -0,0 +1,210 @@
+package interceptor
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/viquitorreis/my-grpc-go-client/internal/tracing"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// W3C trace context keys, see https://www.w3.org/TR/trace-context/.
+const (
+	TraceparentMetadataKey = "traceparent"
+	TracestateMetadataKey  = "tracestate"
+)
+
+// Tracing starts a client span for every call and sends its context to the server. Placed
+// before the retry interceptor, the span covers every attempt and the retries are events of it.
+type Tracing struct {
+	tracer *tracing.Tracer
+}
+
+func NewTracing(tracer *tracing.Tracer) *Tracing {
+	return &Tracing{tracer: tracer}
+}
+
+// remoteParent takes the parent span from the incoming call when the client runs inside a server.
+func remoteParent(ctx context.Context) context.Context {
+	if tracing.SpanContextFromContext(ctx).IsValid() {
+		return ctx
+	}
+
+	incoming, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ctx
+	}
+
+	values := incoming.Get(TraceparentMetadataKey)
+	if len(values) == 0 {
+		return ctx
+	}
+
+	sc, err := tracing.ParseTraceparent(values[0])
+	if err != nil {
+		return ctx
+	}
+
+	sc.TraceState = strings.Join(incoming.Get(TracestateMetadataKey), ",")
+
+	return tracing.ContextWithRemoteParent(ctx, sc)
+}
+
+func (t *Tracing) start(ctx context.Context, method, rpcType string) (context.Context, *tracing.Span) {
+	ctx, span := t.tracer.Start(remoteParent(ctx), strings.TrimPrefix(method, "/"), tracing.SpanKindClient)
+
+	service, name := splitMethod(method)
+
+	span.SetAttribute("rpc.system", "grpc")
+	span.SetAttribute("rpc.service", service)
+	span.SetAttribute("rpc.method", name)
+	span.SetAttribute("rpc.grpc.type", rpcType)
+
+	if id := CorrelationID(ctx); id != "" {
+		span.SetAttribute("correlation_id", id)
+	}
+
+	sc := span.SpanContext()
+
+	md, _ := metadata.FromOutgoingContext(ctx)
+	md = md.Copy()
+	md.Set(TraceparentMetadataKey, sc.Traceparent())
+
+	if sc.TraceState != "" {
+		md.Set(TracestateMetadataKey, sc.TraceState)
+	} else {
+		md.Delete(TracestateMetadataKey)
+	}
+
+	return metadata.NewOutgoingContext(ctx, md), span
+}
+
+func endSpan(span *tracing.Span, err error) {
+	st := status.Convert(err)
+
+	span.SetAttribute("rpc.grpc.status_code", int(st.Code()))
+
+	if err != nil {
+		span.SetStatus(tracing.StatusError, st.Message())
+	} else {
+		span.SetStatus(tracing.StatusOK, "")
+	}
+
+	span.End()
+}
+
+func (t *Tracing) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context, method string, req, reply any,
+		cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption,
+	) error {
+		ctx, span := t.start(ctx, method, "unary")
+
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		endSpan(span, err)
+
+		return err
+	}
+}
+
+func (t *Tracing) StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(
+		ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn,
+		method string, streamer grpc.Streamer, opts ...grpc.CallOption,
+	) (grpc.ClientStream, error) {
+		ctx, span := t.start(ctx, method, rpcType(desc))
+
+		s := &tracingClientStream{
+			ctx:  ctx,
+			desc: desc,
+			span: span,
+		}
+
+		clientStream, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			s.finish(err)
+			return nil, err
+		}
+
+		s.ClientStream = clientStream
+
+		// streams abandoned by the caller never get their final status from RecvMsg
+		go func() {
+			<-clientStream.Context().Done()
+
+			if ctx.Err() != nil {
+				s.finish(nil)
+			}
+		}()
+
+		return s, nil
+	}
+}
+
+type tracingClientStream struct {
+	grpc.ClientStream
+
+	ctx  context.Context
+	desc *grpc.StreamDesc
+	span *tracing.Span
+
+	mu       sync.Mutex
+	sent     int
+	received int
+	done     sync.Once
+}
+
+func (s *tracingClientStream) SendMsg(msg any) error {
+	err := s.ClientStream.SendMsg(msg)
+	if err == nil {
+		s.mu.Lock()
+		s.sent++
+		id := s.sent
+		s.mu.Unlock()
+
+		s.span.AddEvent("message", "message.type", "SENT", "message.id", id)
+	}
+
+	return err
+}
+
+func (s *tracingClientStream) RecvMsg(msg any) error {
+	err := s.ClientStream.RecvMsg(msg)
+	if err != nil {
+		if err == io.EOF {
+			s.finish(nil)
+		} else {
+			s.finish(err)
+		}
+
+		return err
+	}
+
+	s.mu.Lock()
+	s.received++
+	id := s.received
+	s.mu.Unlock()
+
+	s.span.AddEvent("message", "message.type", "RECEIVED", "message.id", id)
+
+	// the single response of a unary-response stream ends it
+	if !s.desc.ServerStreams {
+		s.finish(nil)
+	}
+
+	return nil
+}
+
+func (s *tracingClientStream) finish(err error) {
+	s.done.Do(func() {
+		if err == nil && s.ctx.Err() != nil {
+			err = status.FromContextError(s.ctx.Err()).Err()
+		}
+
+		endSpan(s.span, err)
+	})
+}
@@ -0,0 +1,231 @@
+package interceptor
+
+import (
+	"context"
+	"path"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Direction tells whether a transformer applies to the messages sent or received.
+type Direction int
+
+const (
+	DirectionRequest Direction = iota
+	DirectionResponse
+)
+
+func (d Direction) String() string {
+	if d == DirectionResponse {
+		return "response"
+	}
+
+	return "request"
+}
+
+// TransformFunc changes msg in place, an error fails the call.
+type TransformFunc func(ctx context.Context, method string, msg proto.Message) error
+
+type transformer struct {
+	name string
+	fn   TransformFunc
+}
+
+type transformKey struct {
+	message   protoreflect.FullName
+	direction Direction
+}
+
+// Transformers is a registry of message transformers keyed by message full name and direction.
+// They run in registration order, on every method unless disabled for it.
+type Transformers struct {
+	mu       sync.RWMutex
+	funcs    map[transformKey][]transformer
+	disabled map[string][]string
+}
+
+func NewTransformers() *Transformers {
+	return &Transformers{
+		funcs:    make(map[transformKey][]transformer),
+		disabled: make(map[string][]string),
+	}
+}
+
+// Register adds fn, named for Disable, for the messages of type message going in direction.
+func (t *Transformers) Register(name string, message protoreflect.FullName, direction Direction, fn TransformFunc) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := transformKey{message: message, direction: direction}
+	t.funcs[key] = append(t.funcs[key], transformer{name: name, fn: fn})
+}
+
+// Registered tells whether a transformer was registered under name.
+func (t *Transformers) Registered(name string) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	for _, funcs := range t.funcs {
+		for _, tr := range funcs {
+			if tr.name == name {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Disable turns the named transformers off for the methods matching pattern, a full method name
+// or a path.Match pattern such as /bank.BankService/*. Without names every transformer is off.
+func (t *Transformers) Disable(pattern string, names ...string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(names) == 0 {
+		names = []string{"*"}
+	}
+
+	t.disabled[pattern] = append(t.disabled[pattern], names...)
+}
+
+// Enable undoes Disable for the pattern.
+func (t *Transformers) Enable(pattern string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.disabled, pattern)
+}
+
+func (t *Transformers) enabled(method, name string) bool {
+	for pattern, names := range t.disabled {
+		if ok, _ := path.Match(pattern, method); !ok {
+			continue
+		}
+
+		for _, n := range names {
+			if n == "*" || n == name {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// Apply runs the transformers registered for msg and direction.
+func (t *Transformers) Apply(ctx context.Context, method string, direction Direction, msg any) error {
+	m, ok := msg.(proto.Message)
+	if !ok {
+		return nil
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	for _, tr := range t.funcs[transformKey{message: m.ProtoReflect().Descriptor().FullName(), direction: direction}] {
+		if !t.enabled(method, tr.name) {
+			continue
+		}
+
+		if err := tr.fn(ctx, method, m); err != nil {
+			code := codes.InvalidArgument
+			if direction == DirectionResponse {
+				code = codes.Internal
+			}
+
+			return status.Errorf(code, "%v transformer %v: %v", direction, tr.name, err)
+		}
+	}
+
+	return nil
+}
+
+// StringFieldsTransform applies fn to the string fields of a message whose names match
+// pattern, e.g. *account_number. Nested messages are left alone.
+func StringFieldsTransform(pattern string, fn func(string) string) TransformFunc {
+	return func(_ context.Context, _ string, msg proto.Message) error {
+		m := msg.ProtoReflect()
+		fields := m.Descriptor().Fields()
+
+		for i := 0; i < fields.Len(); i++ {
+			fd := fields.Get(i)
+			if fd.Kind() != protoreflect.StringKind || fd.IsList() || fd.IsMap() {
+				continue
+			}
+
+			if ok, _ := path.Match(pattern, string(fd.Name())); !ok || !m.Has(fd) {
+				continue
+			}
+
+			m.Set(fd, protoreflect.ValueOfString(fn(m.Get(fd).String())))
+		}
+
+		return nil
+	}
+}
+
+func (t *Transformers) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context, method string, req, reply any,
+		cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption,
+	) error {
+		if err := t.Apply(ctx, method, DirectionRequest, req); err != nil {
+			return err
+		}
+
+		if err := invoker(ctx, method, req, reply, cc, opts...); err != nil {
+			return err
+		}
+
+		return t.Apply(ctx, method, DirectionResponse, reply)
+	}
+}
+
+func (t *Transformers) StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(
+		ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn,
+		method string, streamer grpc.Streamer, opts ...grpc.CallOption,
+	) (grpc.ClientStream, error) {
+		clientStream, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			return nil, err
+		}
+
+		return &transformClientStream{
+			ClientStream: clientStream,
+			transformers: t,
+			ctx:          ctx,
+			method:       method,
+		}, nil
+	}
+}
+
+type transformClientStream struct {
+	grpc.ClientStream
+
+	transformers *Transformers
+	ctx          context.Context
+	method       string
+}
+
+func (s *transformClientStream) SendMsg(msg any) error {
+	if err := s.transformers.Apply(s.ctx, s.method, DirectionRequest, msg); err != nil {
+		return err
+	}
+
+	return s.ClientStream.SendMsg(msg)
+}
+
+func (s *transformClientStream) RecvMsg(msg any) error {
+	if err := s.ClientStream.RecvMsg(msg); err != nil {
+		return err
+	}
+
+	return s.transformers.Apply(s.ctx, s.method, DirectionResponse, msg)
+}
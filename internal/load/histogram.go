@@ -0,0 +1,143 @@
+package load
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	histogramMin    = time.Microsecond
+	histogramGrowth = 1.05
+	histogramMax    = 10 * time.Minute
+)
+
+// Histogram records latencies in log-spaced buckets, each 5% wider than the
+// previous one, so percentiles are accurate to within 5% at any scale.
+type Histogram struct {
+	mu     sync.Mutex
+	bounds []time.Duration
+	counts []int64
+	count  int64
+	sum    time.Duration
+	min    time.Duration
+	max    time.Duration
+}
+
+func NewHistogram() *Histogram {
+	var bounds []time.Duration
+	for b := float64(histogramMin); b < float64(histogramMax); b *= histogramGrowth {
+		bounds = append(bounds, time.Duration(b))
+	}
+
+	bounds = append(bounds, histogramMax)
+
+	return &Histogram{
+		bounds: bounds,
+		// the extra bucket holds everything above histogramMax
+		counts: make([]int64, len(bounds)+1),
+	}
+}
+
+func (h *Histogram) bucket(d time.Duration) int {
+	if d <= histogramMin {
+		return 0
+	}
+
+	i := int(math.Ceil(math.Log(float64(d)/float64(histogramMin)) / math.Log(histogramGrowth)))
+
+	// float rounding may land one bucket off
+	for i > 0 && i <= len(h.bounds) && d <= h.bounds[i-1] {
+		i--
+	}
+
+	for i < len(h.bounds) && d > h.bounds[i] {
+		i++
+	}
+
+	return i
+}
+
+func (h *Histogram) Record(d time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.counts[h.bucket(d)]++
+
+	if h.count == 0 || d < h.min {
+		h.min = d
+	}
+
+	if d > h.max {
+		h.max = d
+	}
+
+	h.count++
+	h.sum += d
+}
+
+func (h *Histogram) Count() int64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.count
+}
+
+// Percentile returns the upper bound of the bucket holding the p-th percentile (0 < p <= 100).
+func (h *Histogram) Percentile(p float64) time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.count == 0 {
+		return 0
+	}
+
+	rank := int64(math.Ceil(p / 100 * float64(h.count)))
+
+	var seen int64
+	for i, c := range h.counts {
+		seen += c
+		if seen >= rank {
+			if i >= len(h.bounds) {
+				return h.max
+			}
+
+			return min(h.bounds[i], h.max)
+		}
+	}
+
+	return h.max
+}
+
+type LatencySummary struct {
+	Min  time.Duration `json:"min_ns"`
+	Mean time.Duration `json:"mean_ns"`
+	P50  time.Duration `json:"p50_ns"`
+	P90  time.Duration `json:"p90_ns"`
+	P95  time.Duration `json:"p95_ns"`
+	P99  time.Duration `json:"p99_ns"`
+	P999 time.Duration `json:"p999_ns"`
+	Max  time.Duration `json:"max_ns"`
+}
+
+func (h *Histogram) Summary() LatencySummary {
+	s := LatencySummary{
+		P50:  h.Percentile(50),
+		P90:  h.Percentile(90),
+		P95:  h.Percentile(95),
+		P99:  h.Percentile(99),
+		P999: h.Percentile(99.9),
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s.Min = h.min
+	s.Max = h.max
+
+	if h.count > 0 {
+		s.Mean = h.sum / time.Duration(h.count)
+	}
+
+	return s
+}
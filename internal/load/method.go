@@ -0,0 +1,171 @@
+package load
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"sync/atomic"
+	"text/template"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+
+	// register the services the client knows about
+	_ "github.com/viquitorreis/my-grpc-proto/protogen/go/bank"
+	_ "github.com/viquitorreis/my-grpc-proto/protogen/go/hello"
+	_ "github.com/viquitorreis/my-grpc-proto/protogen/go/resiliency"
+)
+
+// Method is a gRPC method resolved from the registered proto descriptors.
+type Method struct {
+	FullName string
+	Desc     *grpc.StreamDesc
+	input    protoreflect.MessageType
+	output   protoreflect.MessageType
+}
+
+// Kind is the RPC shape, one of unary, server_stream, client_stream or bidi_stream.
+func (m *Method) Kind() string {
+	switch {
+	case m.Desc.ClientStreams && m.Desc.ServerStreams:
+		return "bidi_stream"
+	case m.Desc.ClientStreams:
+		return "client_stream"
+	case m.Desc.ServerStreams:
+		return "server_stream"
+	default:
+		return "unary"
+	}
+}
+
+func (m *Method) NewRequest() proto.Message {
+	return m.input.New().Interface()
+}
+
+func (m *Method) NewResponse() proto.Message {
+	return m.output.New().Interface()
+}
+
+// ResolveMethod finds a method by its full name, e.g. /bank.BankService/GetCurrentBalance.
+func ResolveMethod(fullName string) (*Method, error) {
+	service, name, ok := strings.Cut(strings.TrimPrefix(fullName, "/"), "/")
+	if !ok {
+		return nil, fmt.Errorf("invalid method name %q, expected /package.Service/Method", fullName)
+	}
+
+	d, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(service))
+	if err != nil {
+		return nil, fmt.Errorf("unknown service %v: %w", service, err)
+	}
+
+	sd, ok := d.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%v is not a service", service)
+	}
+
+	md := sd.Methods().ByName(protoreflect.Name(name))
+	if md == nil {
+		return nil, fmt.Errorf("unknown method %v in service %v", name, service)
+	}
+
+	input, err := protoregistry.GlobalTypes.FindMessageByName(md.Input().FullName())
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := protoregistry.GlobalTypes.FindMessageByName(md.Output().FullName())
+	if err != nil {
+		return nil, err
+	}
+
+	return &Method{
+		FullName: "/" + service + "/" + name,
+		Desc: &grpc.StreamDesc{
+			StreamName:    name,
+			ServerStreams: md.IsStreamingServer(),
+			ClientStreams: md.IsStreamingClient(),
+		},
+		input:  input,
+		output: output,
+	}, nil
+}
+
+// KnownMethods lists the full names of every method of the registered services.
+func KnownMethods() []string {
+	var methods []string
+
+	protoregistry.GlobalFiles.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		for i := 0; i < fd.Services().Len(); i++ {
+			sd := fd.Services().Get(i)
+			for j := 0; j < sd.Methods().Len(); j++ {
+				methods = append(methods, fmt.Sprintf("/%v/%v", sd.FullName(), sd.Methods().Get(j).Name()))
+			}
+		}
+		return true
+	})
+
+	return methods
+}
+
+// RequestTemplate renders request messages from a protojson text/template. Templates can use
+// {{seq}} (a counter shared by all workers), {{uuid}}, {{randInt min max}}, {{randFloat min max}}
+// and {{now}} (RFC 3339).
+type RequestTemplate struct {
+	method *Method
+	tmpl   *template.Template
+	seq    atomic.Int64
+}
+
+func NewRequestTemplate(method *Method, text string) (*RequestTemplate, error) {
+	if strings.TrimSpace(text) == "" {
+		text = "{}"
+	}
+
+	t := &RequestTemplate{method: method}
+
+	tmpl, err := template.New(method.FullName).Funcs(template.FuncMap{
+		"seq":  func() int64 { return t.seq.Add(1) },
+		"uuid": func() string { return uuid.New().String() },
+		"randInt": func(min, max int) int {
+			return min + rand.IntN(max-min+1)
+		},
+		"randFloat": func(min, max float64) float64 {
+			return min + rand.Float64()*(max-min)
+		},
+		"now": func() string { return time.Now().Format(time.RFC3339) },
+	}).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	t.tmpl = tmpl
+
+	// fail early on templates that do not render into the request type
+	if _, err := t.render(); err != nil {
+		return nil, err
+	}
+
+	t.seq.Store(0)
+
+	return t, nil
+}
+
+func (t *RequestTemplate) render() (proto.Message, error) {
+	var buf bytes.Buffer
+	if err := t.tmpl.Execute(&buf, nil); err != nil {
+		return nil, err
+	}
+
+	msg := t.method.NewRequest()
+	if err := protojson.Unmarshal(buf.Bytes(), msg); err != nil {
+		return nil, fmt.Errorf("request template of %v: %w", t.method.FullName, err)
+	}
+
+	return msg, nil
+}
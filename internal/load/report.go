@@ -0,0 +1,160 @@
+package load
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+type StreamSummary struct {
+	SentMessages     int64 `json:"sent_messages"`
+	ReceivedMessages int64 `json:"received_messages"`
+	// MessagesPerSecond is the average message rate of a single stream.
+	MessagesPerSecond float64 `json:"messages_per_second"`
+	// TotalMessagesPerSecond is the message rate of all streams together.
+	TotalMessagesPerSecond float64 `json:"total_messages_per_second"`
+}
+
+type Report struct {
+	Method      string         `json:"method"`
+	Kind        string         `json:"kind"`
+	Mode        string         `json:"mode"`
+	Concurrency int            `json:"concurrency,omitempty"`
+	TargetQPS   float64        `json:"target_qps,omitempty"`
+	Duration    time.Duration  `json:"duration_ns"`
+	Requests    int            `json:"requests"`
+	Dropped     int            `json:"dropped,omitempty"`
+	Throughput  float64        `json:"throughput_rps"`
+	Latency     LatencySummary `json:"latency"`
+	Codes       map[string]int `json:"codes"`
+	Streams     *StreamSummary `json:"streams,omitempty"`
+}
+
+func (r *Runner) report(measured time.Duration) *Report {
+	r.stats.mu.Lock()
+	defer r.stats.mu.Unlock()
+
+	report := &Report{
+		Method:   r.method.FullName,
+		Kind:     r.method.Kind(),
+		Mode:     "closed-loop",
+		Duration: measured,
+		Requests: r.stats.requests,
+		Dropped:  r.stats.dropped,
+		Latency:  r.latency.Summary(),
+		Codes:    make(map[string]int, len(r.stats.codes)),
+	}
+
+	if r.settings.QPS > 0 {
+		report.Mode = "open-loop"
+		report.TargetQPS = r.settings.QPS
+	} else {
+		report.Concurrency = r.settings.Concurrency
+	}
+
+	if measured > 0 {
+		report.Throughput = float64(r.stats.requests) / measured.Seconds()
+	}
+
+	for code, n := range r.stats.codes {
+		report.Codes[code.String()] = n
+	}
+
+	if report.Kind != "unary" {
+		streams := &StreamSummary{
+			SentMessages:     r.stats.sent,
+			ReceivedMessages: r.stats.received,
+		}
+
+		if len(r.stats.streamRates) > 0 {
+			var sum float64
+			for _, rate := range r.stats.streamRates {
+				sum += rate
+			}
+
+			streams.MessagesPerSecond = sum / float64(len(r.stats.streamRates))
+		}
+
+		if measured > 0 {
+			streams.TotalMessagesPerSecond = float64(r.stats.sent+r.stats.received) / measured.Seconds()
+		}
+
+		report.Streams = streams
+	}
+
+	return report
+}
+
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(r)
+}
+
+func (r *Report) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "Method\t%v (%v)\n", r.Method, r.Kind)
+
+	if r.Mode == "open-loop" {
+		fmt.Fprintf(tw, "Mode\t%v, target %.1f qps\n", r.Mode, r.TargetQPS)
+	} else {
+		fmt.Fprintf(tw, "Mode\t%v, concurrency %d\n", r.Mode, r.Concurrency)
+	}
+
+	fmt.Fprintf(tw, "Duration\t%v\n", r.Duration.Round(time.Millisecond))
+	fmt.Fprintf(tw, "Requests\t%d\n", r.Requests)
+
+	if r.Dropped > 0 {
+		fmt.Fprintf(tw, "Dropped\t%d\n", r.Dropped)
+	}
+
+	fmt.Fprintf(tw, "Throughput\t%.2f req/s\n", r.Throughput)
+	fmt.Fprintln(tw)
+
+	fmt.Fprintln(tw, "Latency\t")
+	for _, row := range []struct {
+		name string
+		d    time.Duration
+	}{
+		{"min", r.Latency.Min},
+		{"mean", r.Latency.Mean},
+		{"p50", r.Latency.P50},
+		{"p90", r.Latency.P90},
+		{"p95", r.Latency.P95},
+		{"p99", r.Latency.P99},
+		{"p99.9", r.Latency.P999},
+		{"max", r.Latency.Max},
+	} {
+		fmt.Fprintf(tw, "  %v\t%v\n", row.name, row.d.Round(time.Microsecond))
+	}
+
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, "Status codes\t")
+
+	var names []string
+	for name := range r.Codes {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(tw, "  %v\t%d\n", name, r.Codes[name])
+	}
+
+	if r.Streams != nil {
+		fmt.Fprintln(tw)
+		fmt.Fprintln(tw, "Streams\t")
+		fmt.Fprintf(tw, "  sent messages\t%d\n", r.Streams.SentMessages)
+		fmt.Fprintf(tw, "  received messages\t%d\n", r.Streams.ReceivedMessages)
+		fmt.Fprintf(tw, "  msg/s per stream\t%.2f\n", r.Streams.MessagesPerSecond)
+		fmt.Fprintf(tw, "  msg/s total\t%.2f\n", r.Streams.TotalMessagesPerSecond)
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,268 @@
+package load
+
+import (
+	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type Settings struct {
+	// Concurrency is the number of workers of a closed-loop run, ignored when QPS is set.
+	Concurrency int
+	// QPS switches to an open-loop run that starts calls at this rate whatever their latency.
+	QPS float64
+	// MaxInFlight caps the open-loop calls running at once, calls over it are counted as dropped.
+	MaxInFlight int
+	Warmup      time.Duration
+	Duration    time.Duration
+	// Timeout is the deadline of each call, 0 for none.
+	Timeout time.Duration
+	// Messages is the number of requests sent on client and bidi streams.
+	Messages int
+}
+
+// Runner drives one method with generated requests and collects the results.
+type Runner struct {
+	conn     *grpc.ClientConn
+	method   *Method
+	template *RequestTemplate
+	settings Settings
+
+	latency *Histogram
+	stats   *stats
+}
+
+type stats struct {
+	mu       sync.Mutex
+	codes    map[codes.Code]int
+	requests int
+	dropped  int
+	sent     int64
+	received int64
+	// streamRates are the received (or sent, for client streams) messages per second of each stream
+	streamRates []float64
+}
+
+func NewRunner(conn *grpc.ClientConn, method *Method, template *RequestTemplate, settings Settings) *Runner {
+	if settings.Concurrency <= 0 {
+		settings.Concurrency = 1
+	}
+
+	if settings.MaxInFlight <= 0 {
+		settings.MaxInFlight = 1000
+	}
+
+	if settings.Messages <= 0 {
+		settings.Messages = 1
+	}
+
+	return &Runner{
+		conn:     conn,
+		method:   method,
+		template: template,
+		settings: settings,
+		latency:  NewHistogram(),
+		stats:    &stats{codes: make(map[codes.Code]int)},
+	}
+}
+
+// Run blocks for the warmup and the measured duration, then returns the report.
+// Calls started during the warmup are executed but not recorded.
+func (r *Runner) Run(ctx context.Context) *Report {
+	ctx, cancel := context.WithTimeout(ctx, r.settings.Warmup+r.settings.Duration)
+	defer cancel()
+
+	measureFrom := time.Now().Add(r.settings.Warmup)
+
+	if r.settings.QPS > 0 {
+		r.openLoop(ctx, measureFrom)
+	} else {
+		r.closedLoop(ctx, measureFrom)
+	}
+
+	return r.report(time.Since(measureFrom))
+}
+
+func (r *Runner) closedLoop(ctx context.Context, measureFrom time.Time) {
+	var wg sync.WaitGroup
+
+	for i := 0; i < r.settings.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for ctx.Err() == nil {
+				r.call(ctx, measureFrom)
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+func (r *Runner) openLoop(ctx context.Context, measureFrom time.Time) {
+	var (
+		wg       sync.WaitGroup
+		inFlight atomic.Int64
+	)
+
+	// rates above 1e9 would round the interval down to 0, which NewTicker panics on
+	ticker := time.NewTicker(max(time.Duration(float64(time.Second)/r.settings.QPS), time.Nanosecond))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		case <-ticker.C:
+		}
+
+		if inFlight.Load() >= int64(r.settings.MaxInFlight) {
+			if !time.Now().Before(measureFrom) {
+				r.stats.mu.Lock()
+				r.stats.dropped++
+				r.stats.mu.Unlock()
+			}
+
+			continue
+		}
+
+		inFlight.Add(1)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer inFlight.Add(-1)
+
+			r.call(ctx, measureFrom)
+		}()
+	}
+}
+
+// call runs one call of any shape and records it unless it started during the warmup
+// or was cut by the end of the run.
+func (r *Runner) call(runCtx context.Context, measureFrom time.Time) {
+	ctx := runCtx
+	if r.settings.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.settings.Timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+
+	var (
+		err            error
+		sent, received int
+	)
+
+	if r.method.Kind() == "unary" {
+		err = r.unary(ctx)
+	} else {
+		sent, received, err = r.stream(ctx)
+	}
+
+	elapsed := time.Since(start)
+
+	if start.Before(measureFrom) {
+		return
+	}
+
+	// calls cut by the end of the run say nothing about the server
+	if code := status.Code(err); runCtx.Err() != nil && (code == codes.DeadlineExceeded || code == codes.Canceled) {
+		return
+	}
+
+	r.latency.Record(elapsed)
+
+	r.stats.mu.Lock()
+	defer r.stats.mu.Unlock()
+
+	r.stats.requests++
+	r.stats.codes[status.Code(err)]++
+
+	if r.method.Kind() != "unary" {
+		r.stats.sent += int64(sent)
+		r.stats.received += int64(received)
+
+		messages := received
+		if r.method.Kind() == "client_stream" {
+			messages = sent
+		}
+
+		if elapsed > 0 {
+			r.stats.streamRates = append(r.stats.streamRates, float64(messages)/elapsed.Seconds())
+		}
+	}
+}
+
+func (r *Runner) unary(ctx context.Context) error {
+	req, err := r.template.render()
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return r.conn.Invoke(ctx, r.method.FullName, req, r.method.NewResponse())
+}
+
+func (r *Runner) stream(ctx context.Context) (sent, received int, err error) {
+	stream, err := r.conn.NewStream(ctx, r.method.Desc, r.method.FullName)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	messages := 1
+	if r.method.Desc.ClientStreams {
+		messages = r.settings.Messages
+	}
+
+	var sendErr error
+	sendDone := make(chan struct{})
+
+	go func() {
+		defer close(sendDone)
+		defer stream.CloseSend()
+
+		for i := 0; i < messages; i++ {
+			req, err := r.template.render()
+			if err != nil {
+				sendErr = status.Error(codes.InvalidArgument, err.Error())
+				return
+			}
+
+			// the real error, if any, is returned by RecvMsg
+			if err := stream.SendMsg(req); err != nil {
+				return
+			}
+
+			sent++
+		}
+	}()
+
+	for {
+		err = stream.RecvMsg(r.method.NewResponse())
+		if err != nil {
+			break
+		}
+
+		received++
+	}
+
+	<-sendDone
+
+	if sendErr != nil {
+		return sent, received, sendErr
+	}
+
+	if err == io.EOF {
+		err = nil
+	}
+
+	return sent, received, err
+}
@@ -0,0 +1,279 @@
+package mock
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/viquitorreis/my-grpc-go-client/internal/capture"
+	"github.com/viquitorreis/my-grpc-go-client/internal/load"
+	"github.com/viquitorreis/my-grpc-go-client/internal/redact"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+type MatchMode string
+
+const (
+	// MatchBody serves a recorded call of the same method whose requests are equal to the incoming ones.
+	MatchBody MatchMode = "body"
+	// MatchMethod serves any recorded call of the same method.
+	MatchMethod MatchMode = "method"
+)
+
+type Settings struct {
+	Match MatchMode
+	// Ignore are request fields left out of the comparison, as full field names or path.Match patterns.
+	Ignore []string
+	// Redactor masks the incoming requests the way the recorded ones were, redact.Default() when nil.
+	Redactor *redact.Redactor
+}
+
+// Server answers calls with the responses of a capture, see package capture.
+type Server struct {
+	settings Settings
+	calls    map[string][]*capture.Call
+
+	mu   sync.Mutex
+	uses map[*capture.Call]int
+}
+
+// NewServer keeps the calls of the capture that ended, incomplete ones can not be reproduced.
+func NewServer(calls []*capture.Call, settings Settings) *Server {
+	if settings.Match == "" {
+		settings.Match = MatchBody
+	}
+
+	if settings.Redactor == nil {
+		settings.Redactor = redact.Default()
+	}
+
+	s := &Server{
+		settings: settings,
+		calls:    make(map[string][]*capture.Call),
+		uses:     make(map[*capture.Call]int),
+	}
+
+	for _, c := range calls {
+		if c.Status != nil {
+			s.calls[c.Method] = append(s.calls[c.Method], c)
+		}
+	}
+
+	return s
+}
+
+// Methods lists the methods the server has recorded calls of.
+func (s *Server) Methods() []string {
+	var methods []string
+	for m := range s.calls {
+		methods = append(methods, m)
+	}
+
+	sort.Strings(methods)
+
+	return methods
+}
+
+// GRPCServer returns a server that handles every method through the capture.
+func (s *Server) GRPCServer(opts ...grpc.ServerOption) *grpc.Server {
+	return grpc.NewServer(append(opts, grpc.UnknownServiceHandler(s.handle))...)
+}
+
+func (s *Server) handle(_ any, stream grpc.ServerStream) error {
+	fullName, _ := grpc.MethodFromServerStream(stream)
+
+	method, err := load.ResolveMethod(fullName)
+	if err != nil {
+		return status.Errorf(codes.Unimplemented, "unknown method %v", fullName)
+	}
+
+	if len(s.calls[method.FullName]) == 0 {
+		return status.Errorf(codes.Unimplemented, "no recorded calls of %v", method.FullName)
+	}
+
+	requests, err := receive(stream, method)
+	if err != nil {
+		return err
+	}
+
+	call, err := s.match(method, requests)
+	if err != nil {
+		return err
+	}
+
+	return respond(stream, method, call)
+}
+
+// receive reads what is needed to pick a recorded call: the single request of unary and
+// server-streaming calls, every request of client streams, and the first request of bidi
+// streams, whose other requests are drained while the responses are sent.
+func receive(stream grpc.ServerStream, method *load.Method) ([]proto.Message, error) {
+	var requests []proto.Message
+
+	for {
+		req := method.NewRequest()
+
+		err := stream.RecvMsg(req)
+		if err == io.EOF {
+			return requests, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		requests = append(requests, req)
+
+		if !method.Desc.ClientStreams {
+			return requests, nil
+		}
+
+		if method.Desc.ServerStreams {
+			go func() {
+				for stream.RecvMsg(method.NewRequest()) == nil {
+				}
+			}()
+
+			return requests, nil
+		}
+	}
+}
+
+func (s *Server) match(method *load.Method, requests []proto.Message) (*capture.Call, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var best *capture.Call
+
+	for _, c := range s.calls[method.FullName] {
+		if s.settings.Match == MatchBody && !s.sameRequests(method, c.Sent, requests) {
+			continue
+		}
+
+		// repeated requests go through their recordings in order
+		if best == nil || s.uses[c] < s.uses[best] {
+			best = c
+		}
+	}
+
+	if best == nil {
+		return nil, status.Errorf(codes.NotFound, "no recorded call of %v matches the request", method.FullName)
+	}
+
+	s.uses[best]++
+
+	return best, nil
+}
+
+// sameRequests compares the incoming requests with the recorded ones. Bidi streams only
+// compare the requests received so far.
+func (s *Server) sameRequests(method *load.Method, recorded []json.RawMessage, requests []proto.Message) bool {
+	if method.Kind() == "bidi_stream" {
+		if len(recorded) < len(requests) {
+			return false
+		}
+
+		recorded = recorded[:len(requests)]
+	}
+
+	if len(recorded) != len(requests) {
+		return false
+	}
+
+	for i, body := range recorded {
+		want := method.NewRequest()
+		if err := unmarshal(body, want); err != nil {
+			return false
+		}
+
+		// the recorded requests were masked, a raw request would never equal them
+		have := proto.Clone(s.settings.Redactor.Redact(requests[i]))
+
+		redact.Strip(want, s.settings.Ignore)
+		redact.Strip(have, s.settings.Ignore)
+
+		if !proto.Equal(want, have) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func respond(stream grpc.ServerStream, method *load.Method, call *capture.Call) error {
+	if header := serverMetadata(call.Header); header.Len() > 0 {
+		if err := stream.SendHeader(header); err != nil {
+			return err
+		}
+	}
+
+	for i, body := range call.Received {
+		res := method.NewResponse()
+		if err := unmarshal(body, res); err != nil {
+			return status.Errorf(codes.Internal, "recorded response %d of call %v: %v", i, call.ID, err)
+		}
+
+		if err := stream.SendMsg(res); err != nil {
+			return err
+		}
+	}
+
+	stream.SetTrailer(serverMetadata(call.Trailer))
+
+	code, ok := parseCode(call.Status.Code)
+	if !ok {
+		return status.Errorf(codes.Internal, "call %v recorded with unknown code %q", call.ID, call.Status.Code)
+	}
+
+	if code == codes.OK {
+		return nil
+	}
+
+	return status.Error(code, call.Status.Message)
+}
+
+// serverMetadata drops the keys the grpc transport sets itself.
+func serverMetadata(recorded metadata.MD) metadata.MD {
+	md := metadata.MD{}
+
+	for k, values := range recorded {
+		if k == "content-type" || strings.HasPrefix(k, ":") || strings.HasPrefix(k, "grpc-") {
+			continue
+		}
+
+		md[k] = values
+	}
+
+	return md
+}
+
+func unmarshal(body json.RawMessage, msg proto.Message) error {
+	if len(body) == 0 {
+		return nil
+	}
+
+	if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(body, msg); err != nil {
+		return fmt.Errorf("invalid recorded body: %w", err)
+	}
+
+	return nil
+}
+
+// parseCode parses the code names written in captures, e.g. NotFound.
+func parseCode(name string) (codes.Code, bool) {
+	for c := codes.OK; c <= codes.Unauthenticated; c++ {
+		if c.String() == name {
+			return c, true
+		}
+	}
+
+	return 0, false
+}
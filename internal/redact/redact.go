@@ -0,0 +1,225 @@
+package redact
+
+import (
+	"hash/fnv"
+	"path"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"github.com/viquitorreis/my-grpc-go-client/internal/config"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Redactor masks the fields of protobuf messages matching the configured names.
+type Redactor struct {
+	fields      []string
+	hashNumbers bool
+}
+
+func New(cfg config.Redaction) *Redactor {
+	return &Redactor{
+		fields:      cfg.Fields,
+		hashNumbers: cfg.Numbers == "hash",
+	}
+}
+
+var defaultRedactor atomic.Pointer[Redactor]
+
+func init() {
+	defaultRedactor.Store(New(config.Default().Redaction))
+}
+
+// Default returns the redactor used by the code that has none passed in, see SetDefault.
+func Default() *Redactor {
+	return defaultRedactor.Load()
+}
+
+func SetDefault(r *Redactor) {
+	defaultRedactor.Store(r)
+}
+
+// Message is a shortcut for Default().Redact(m).
+func Message(m proto.Message) proto.Message {
+	return Default().Redact(m)
+}
+
+func matches(fields []string, fd protoreflect.FieldDescriptor) bool {
+	name := string(fd.FullName())
+
+	for _, f := range fields {
+		if ok, _ := path.Match(f, name); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Redact returns a masked copy of m, m itself is left untouched.
+func (r *Redactor) Redact(m proto.Message) proto.Message {
+	if m == nil || len(r.fields) == 0 {
+		return m
+	}
+
+	c := proto.Clone(m)
+	walk(c.ProtoReflect(), r.fields, r.redactField)
+
+	return c
+}
+
+// Redacted returns the full names of the fields set in m that Redact masks, nil when none.
+// It is meant for m itself, not its redacted copy: zeroed fields are no longer set there.
+func (r *Redactor) Redacted(m proto.Message) []string {
+	if m == nil || len(r.fields) == 0 {
+		return nil
+	}
+
+	var names []string
+	walk(m.ProtoReflect(), r.fields, func(_ protoreflect.Message, fd protoreflect.FieldDescriptor) {
+		if name := string(fd.FullName()); !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	})
+
+	return names
+}
+
+// Strip clears the fields of m matching the patterns, in place.
+func Strip(m proto.Message, fields []string) {
+	if m == nil || len(fields) == 0 {
+		return
+	}
+
+	walk(m.ProtoReflect(), fields, func(m protoreflect.Message, fd protoreflect.FieldDescriptor) {
+		m.Clear(fd)
+	})
+}
+
+// walk calls apply on the fields of m and its nested messages matching the patterns.
+func walk(m protoreflect.Message, fields []string, apply func(protoreflect.Message, protoreflect.FieldDescriptor)) {
+	// fields are collected first, a message must not be changed while ranging over it
+	var set []protoreflect.FieldDescriptor
+	m.Range(func(fd protoreflect.FieldDescriptor, _ protoreflect.Value) bool {
+		set = append(set, fd)
+		return true
+	})
+
+	for _, fd := range set {
+		if matches(fields, fd) {
+			apply(m, fd)
+			continue
+		}
+
+		if !isMessage(fd) {
+			continue
+		}
+
+		v := m.Get(fd)
+
+		switch {
+		case fd.IsList():
+			for i := 0; i < v.List().Len(); i++ {
+				walk(v.List().Get(i).Message(), fields, apply)
+			}
+		case fd.IsMap():
+			v.Map().Range(func(_ protoreflect.MapKey, mv protoreflect.Value) bool {
+				walk(mv.Message(), fields, apply)
+				return true
+			})
+		default:
+			walk(v.Message(), fields, apply)
+		}
+	}
+}
+
+func isMessage(fd protoreflect.FieldDescriptor) bool {
+	if fd.IsMap() {
+		fd = fd.MapValue()
+	}
+
+	return fd.Kind() == protoreflect.MessageKind || fd.Kind() == protoreflect.GroupKind
+}
+
+func (r *Redactor) redactField(m protoreflect.Message, fd protoreflect.FieldDescriptor) {
+	// a matching message field is dropped as a whole
+	if isMessage(fd) {
+		m.Clear(fd)
+		return
+	}
+
+	v := m.Get(fd)
+
+	switch {
+	case fd.IsList():
+		list := v.List()
+		for i := 0; i < list.Len(); i++ {
+			list.Set(i, r.mask(fd, list.Get(i)))
+		}
+	case fd.IsMap():
+		mp := v.Map()
+		var keys []protoreflect.MapKey
+		mp.Range(func(k protoreflect.MapKey, _ protoreflect.Value) bool {
+			keys = append(keys, k)
+			return true
+		})
+
+		for _, k := range keys {
+			mp.Set(k, r.mask(fd.MapValue(), mp.Get(k)))
+		}
+	default:
+		m.Set(fd, r.mask(fd, v))
+	}
+}
+
+func (r *Redactor) mask(fd protoreflect.FieldDescriptor, v protoreflect.Value) protoreflect.Value {
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(maskString(v.String()))
+	case protoreflect.BytesKind:
+		if r.hashNumbers {
+			f := fnv.New64a()
+			f.Write(v.Bytes())
+			return protoreflect.ValueOfBytes(f.Sum(nil))
+		}
+
+		return protoreflect.ValueOfBytes(nil)
+	case protoreflect.BoolKind:
+		return protoreflect.ValueOfBool(false)
+	case protoreflect.EnumKind:
+		return protoreflect.ValueOfEnum(0)
+	}
+
+	var h uint64
+	if r.hashNumbers {
+		f := fnv.New64a()
+		f.Write([]byte(v.String()))
+		h = f.Sum64()
+	}
+
+	switch fd.Kind() {
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return protoreflect.ValueOfInt32(int32(h))
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return protoreflect.ValueOfInt64(int64(h))
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return protoreflect.ValueOfUint32(uint32(h))
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return protoreflect.ValueOfUint64(h)
+	case protoreflect.FloatKind:
+		return protoreflect.ValueOfFloat32(float32(h % 1_000_000))
+	case protoreflect.DoubleKind:
+		return protoreflect.ValueOfFloat64(float64(h % 1_000_000))
+	}
+
+	return v
+}
+
+// maskString keeps the last quarter of s, at most 4 characters, so that values stay recognizable.
+func maskString(s string) string {
+	runes := []rune(s)
+	keep := min(len(runes)/4, 4)
+
+	return strings.Repeat("*", len(runes)-keep) + string(runes[len(runes)-keep:])
+}
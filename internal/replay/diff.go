@@ -0,0 +1,103 @@
+package replay
+
+import (
+	"fmt"
+	"sort"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// diffMessages lists the fields of x and y that differ, one line per field.
+func diffMessages(path string, x, y protoreflect.Message) []string {
+	var diffs []string
+
+	fields := x.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if !x.Has(fd) && !y.Has(fd) {
+			continue
+		}
+
+		diffs = append(diffs, diffField(path+"."+string(fd.Name()), fd, x.Get(fd), y.Get(fd))...)
+	}
+
+	return diffs
+}
+
+func diffField(path string, fd protoreflect.FieldDescriptor, x, y protoreflect.Value) []string {
+	if x.Equal(y) {
+		return nil
+	}
+
+	switch {
+	case fd.IsList():
+		lx, ly := x.List(), y.List()
+		if lx.Len() != ly.Len() {
+			return []string{fmt.Sprintf("%v: expected %d elements, got %d", path, lx.Len(), ly.Len())}
+		}
+
+		var diffs []string
+		for i := 0; i < lx.Len(); i++ {
+			diffs = append(diffs, diffValue(fmt.Sprintf("%v[%d]", path, i), fd, lx.Get(i), ly.Get(i))...)
+		}
+
+		return diffs
+	case fd.IsMap():
+		mx, my := x.Map(), y.Map()
+
+		keys := make(map[string]protoreflect.MapKey)
+		for _, m := range []protoreflect.Map{mx, my} {
+			m.Range(func(k protoreflect.MapKey, _ protoreflect.Value) bool {
+				keys[k.String()] = k
+				return true
+			})
+		}
+
+		var names []string
+		for name := range keys {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+
+		var diffs []string
+		for _, name := range names {
+			k := keys[name]
+			elem := fmt.Sprintf("%v[%v]", path, name)
+
+			switch {
+			case !mx.Has(k):
+				diffs = append(diffs, fmt.Sprintf("%v: unexpected, got %v", elem, formatValue(my.Get(k))))
+			case !my.Has(k):
+				diffs = append(diffs, fmt.Sprintf("%v: expected %v, missing", elem, formatValue(mx.Get(k))))
+			default:
+				diffs = append(diffs, diffValue(elem, fd.MapValue(), mx.Get(k), my.Get(k))...)
+			}
+		}
+
+		return diffs
+	}
+
+	return diffValue(path, fd, x, y)
+}
+
+// diffValue compares a single value, recursing into messages.
+func diffValue(path string, fd protoreflect.FieldDescriptor, x, y protoreflect.Value) []string {
+	if x.Equal(y) {
+		return nil
+	}
+
+	if fd.Kind() == protoreflect.MessageKind || fd.Kind() == protoreflect.GroupKind {
+		return diffMessages(path, x.Message(), y.Message())
+	}
+
+	return []string{fmt.Sprintf("%v: expected %v, got %v", path, formatValue(x), formatValue(y))}
+}
+
+func formatValue(v protoreflect.Value) string {
+	if s, ok := v.Interface().(string); ok {
+		return fmt.Sprintf("%q", s)
+	}
+
+	return v.String()
+}
@@ -0,0 +1,127 @@
+package replay
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+type Outcome string
+
+const (
+	OutcomeMatch   Outcome = "match"
+	OutcomeDiff    Outcome = "diff"
+	OutcomeError   Outcome = "error"
+	OutcomeSkipped Outcome = "skipped"
+	// OutcomeNotReplayable is a call whose requests were redacted at recording, sending
+	// the masked values would not reproduce it.
+	OutcomeNotReplayable Outcome = "not_replayable"
+)
+
+type Result struct {
+	CallID   string        `json:"call_id"`
+	Method   string        `json:"method"`
+	Type     string        `json:"type"`
+	Outcome  Outcome       `json:"outcome"`
+	Expected string        `json:"expected_code,omitempty"`
+	Observed string        `json:"observed_code,omitempty"`
+	Duration time.Duration `json:"duration_ns"`
+	Diffs    []string      `json:"diffs,omitempty"`
+	Error    string        `json:"error,omitempty"`
+}
+
+func (r Result) failed(err error) Result {
+	r.Outcome = OutcomeError
+	r.Error = err.Error()
+
+	return r
+}
+
+type Report struct {
+	StartedAt time.Time     `json:"started_at"`
+	Duration  time.Duration `json:"duration_ns"`
+	Results   []Result      `json:"results"`
+}
+
+// Passed is true when at least one call was replayed and every replayed call matched its
+// recording, skipped and not replayable calls aside.
+func (r *Report) Passed() bool {
+	for _, res := range r.Results {
+		if res.Outcome == OutcomeDiff || res.Outcome == OutcomeError {
+			return false
+		}
+	}
+
+	return r.Replayed() > 0
+}
+
+// Replayed is the number of calls that were sent again and compared, i.e. matched or diffed.
+func (r *Report) Replayed() int {
+	n := 0
+	for _, res := range r.Results {
+		if res.Outcome == OutcomeMatch || res.Outcome == OutcomeDiff {
+			n++
+		}
+	}
+
+	return n
+}
+
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(r)
+}
+
+func (r *Report) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "RESULT\tCALL\tMETHOD\tEXPECTED\tOBSERVED\tDURATION")
+
+	counts := make(map[Outcome]int)
+
+	for _, res := range r.Results {
+		counts[res.Outcome]++
+
+		fmt.Fprintf(tw, "%v\t%v\t%v\t%v\t%v\t%v\n",
+			res.Outcome, res.CallID, res.Method, res.Expected, res.Observed, res.Duration.Round(time.Millisecond),
+		)
+	}
+
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	for _, res := range r.Results {
+		if res.Error == "" && len(res.Diffs) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(w, "\n%v %v\n", res.CallID, res.Method)
+
+		if res.Error != "" {
+			fmt.Fprintf(w, "  %v: %v\n", res.Outcome, res.Error)
+		}
+
+		for _, d := range res.Diffs {
+			fmt.Fprintf(w, "  %v\n", d)
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "\n%d call(s) in %v: %d match, %d diff, %d error, %d skipped, %d not replayable\n",
+		len(r.Results), r.Duration.Round(time.Millisecond),
+		counts[OutcomeMatch], counts[OutcomeDiff], counts[OutcomeError], counts[OutcomeSkipped], counts[OutcomeNotReplayable],
+	)
+	if err != nil {
+		return err
+	}
+
+	if r.Replayed() == 0 {
+		_, err = fmt.Fprintln(w, "No call was replayed, the capture has nothing to check")
+	}
+
+	return err
+}
@@ -0,0 +1,306 @@
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/viquitorreis/my-grpc-go-client/internal/capture"
+	"github.com/viquitorreis/my-grpc-go-client/internal/interceptor"
+	"github.com/viquitorreis/my-grpc-go-client/internal/load"
+	"github.com/viquitorreis/my-grpc-go-client/internal/redact"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+type Settings struct {
+	// Timing starts each call at the offset it was recorded at, otherwise the calls
+	// run one after the other as fast as possible.
+	Timing bool
+	// Ignore are volatile fields cleared on both sides before comparing, as full
+	// field names or path.Match patterns, e.g. *.timestamp.
+	Ignore []string
+	// Redactor masks the new responses the way the recorded ones were, redact.Default() when nil.
+	// Calls with a request it would mask are not replayed.
+	Redactor *redact.Redactor
+	// Timeout is the deadline of each call, 0 for none.
+	Timeout time.Duration
+}
+
+// Runner re-issues recorded calls and compares the responses with the recorded ones.
+type Runner struct {
+	conn     *grpc.ClientConn
+	settings Settings
+}
+
+func NewRunner(conn *grpc.ClientConn, settings Settings) *Runner {
+	if settings.Redactor == nil {
+		settings.Redactor = redact.Default()
+	}
+
+	return &Runner{conn: conn, settings: settings}
+}
+
+func (r *Runner) Run(ctx context.Context, calls []*capture.Call) *Report {
+	report := &Report{
+		StartedAt: time.Now(),
+		Results:   make([]Result, len(calls)),
+	}
+
+	if r.settings.Timing && len(calls) > 0 {
+		var wg sync.WaitGroup
+		first := calls[0].Start
+
+		for i, c := range calls {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				select {
+				case <-time.After(time.Until(report.StartedAt.Add(c.Start.Sub(first)))):
+				case <-ctx.Done():
+				}
+
+				report.Results[i] = r.replay(ctx, c)
+			}()
+		}
+
+		wg.Wait()
+	} else {
+		for i, c := range calls {
+			report.Results[i] = r.replay(ctx, c)
+		}
+	}
+
+	report.Duration = time.Since(report.StartedAt)
+
+	return report
+}
+
+// response is what a call returned, recorded or replayed.
+type response struct {
+	code     string
+	messages []proto.Message
+}
+
+func (r *Runner) replay(ctx context.Context, c *capture.Call) Result {
+	res := Result{
+		CallID: c.ID,
+		Method: c.Method,
+		Type:   c.Type,
+	}
+
+	if c.Status == nil {
+		res.Outcome = OutcomeSkipped
+		res.Error = "the capture ended before the call"
+		return res
+	}
+
+	res.Expected = c.Status.Code
+
+	method, err := load.ResolveMethod(c.Method)
+	if err != nil {
+		return res.failed(err)
+	}
+
+	// masked values, or zeroed ones missing from the bodies, would not reproduce the call
+	if len(c.SentRedacted) > 0 {
+		res.Outcome = OutcomeNotReplayable
+		res.Error = fmt.Sprintf("the requests were redacted at recording: %v", strings.Join(c.SentRedacted, ", "))
+		return res
+	}
+
+	requests, err := recordedRequests(method, c)
+	if err != nil {
+		return res.failed(err)
+	}
+
+	expected, err := recordedResponse(method, c)
+	if err != nil {
+		return res.failed(err)
+	}
+
+	if r.settings.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.settings.Timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	got, err := r.call(metadata.NewOutgoingContext(ctx, replayMetadata(c.Metadata)), method, requests)
+	res.Duration = time.Since(start)
+
+	if err != nil {
+		return res.failed(err)
+	}
+
+	res.Observed = got.code
+	res.Diffs = r.compare(expected, got)
+
+	res.Outcome = OutcomeMatch
+	if len(res.Diffs) > 0 {
+		res.Outcome = OutcomeDiff
+	}
+
+	return res
+}
+
+// recordedRequests decodes the requests of c.
+func recordedRequests(method *load.Method, c *capture.Call) ([]proto.Message, error) {
+	requests := []proto.Message{}
+
+	for i, body := range c.Sent {
+		msg, err := unmarshal(body, method.NewRequest())
+		if err != nil {
+			return nil, fmt.Errorf("recorded request %d: %w", i, err)
+		}
+
+		requests = append(requests, msg)
+	}
+
+	return requests, nil
+}
+
+func recordedResponse(method *load.Method, c *capture.Call) (*response, error) {
+	expected := &response{code: c.Status.Code}
+
+	for i, body := range c.Received {
+		msg, err := unmarshal(body, method.NewResponse())
+		if err != nil {
+			return nil, fmt.Errorf("recorded response %d: %w", i, err)
+		}
+
+		expected.messages = append(expected.messages, msg)
+	}
+
+	return expected, nil
+}
+
+func unmarshal(body json.RawMessage, msg proto.Message) (proto.Message, error) {
+	if len(body) == 0 {
+		return msg, nil
+	}
+
+	err := protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(body, msg)
+
+	return msg, err
+}
+
+// replayMetadata is the recorded request metadata without the values masked at recording
+// and the ones that identify the recorded attempt, the interceptors send new ones.
+func replayMetadata(recorded metadata.MD) metadata.MD {
+	md := metadata.MD{}
+
+	for k, values := range recorded {
+		if k == interceptor.RequestIDMetadataKey || k == interceptor.RetryAttemptMetadataKey {
+			continue
+		}
+
+		for _, v := range values {
+			if v != "***" {
+				md.Append(k, v)
+			}
+		}
+	}
+
+	return md
+}
+
+// call issues the recorded requests. The returned error is about the replay itself,
+// the status of the call is part of the response.
+func (r *Runner) call(ctx context.Context, method *load.Method, requests []proto.Message) (*response, error) {
+	got := &response{}
+
+	if method.Kind() == "unary" {
+		if len(requests) != 1 {
+			return nil, fmt.Errorf("unary call with %d recorded requests", len(requests))
+		}
+
+		reply := method.NewResponse()
+		err := r.conn.Invoke(ctx, method.FullName, requests[0], reply)
+		if err == nil {
+			got.messages = append(got.messages, reply)
+		}
+
+		got.code = status.Code(err).String()
+
+		return got, nil
+	}
+
+	stream, err := r.conn.NewStream(ctx, method.Desc, method.FullName)
+	if err != nil {
+		got.code = status.Code(err).String()
+		return got, nil
+	}
+
+	go func() {
+		defer stream.CloseSend()
+
+		for _, req := range requests {
+			// the real error, if any, is returned by RecvMsg
+			if err := stream.SendMsg(req); err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		msg := method.NewResponse()
+		if err = stream.RecvMsg(msg); err != nil {
+			break
+		}
+
+		got.messages = append(got.messages, msg)
+	}
+
+	if err == io.EOF {
+		err = nil
+	}
+
+	got.code = status.Code(err).String()
+
+	return got, nil
+}
+
+func (r *Runner) compare(expected, got *response) []string {
+	var diffs []string
+
+	if expected.code != got.code {
+		diffs = append(diffs, fmt.Sprintf("status: expected %v, got %v", expected.code, got.code))
+	}
+
+	if len(expected.messages) != len(got.messages) {
+		diffs = append(diffs, fmt.Sprintf("messages: expected %d, got %d", len(expected.messages), len(got.messages)))
+	}
+
+	for i := 0; i < min(len(expected.messages), len(got.messages)); i++ {
+		want := proto.Clone(expected.messages[i])
+		have := r.settings.Redactor.Redact(got.messages[i])
+		if have == got.messages[i] {
+			have = proto.Clone(have)
+		}
+
+		redact.Strip(want, r.settings.Ignore)
+		redact.Strip(have, r.settings.Ignore)
+
+		if proto.Equal(want, have) {
+			continue
+		}
+
+		prefix := fmt.Sprintf("message[%d]", i)
+		if len(expected.messages) == 1 {
+			prefix = "message"
+		}
+
+		diffs = append(diffs, diffMessages(prefix, want.ProtoReflect(), have.ProtoReflect())...)
+	}
+
+	return diffs
+}
@@ -0,0 +1,101 @@
+package scenario
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+type Result struct {
+	Name        string         `json:"name"`
+	RPC         RPC            `json:"rpc"`
+	Patterns    []Pattern      `json:"patterns,omitempty"`
+	Passed      bool           `json:"passed"`
+	Calls       int            `json:"calls"`
+	Messages    int            `json:"messages,omitempty"`
+	Duration    time.Duration  `json:"duration_ns"`
+	AverageCall time.Duration  `json:"average_call_ns"`
+	Expected    []string       `json:"expected_codes"`
+	Observed    map[string]int `json:"observed_codes"`
+	Error       string         `json:"error,omitempty"`
+}
+
+type Report struct {
+	StartedAt time.Time     `json:"started_at"`
+	Duration  time.Duration `json:"duration_ns"`
+	Results   []Result      `json:"results"`
+}
+
+func (r *Report) Passed() bool {
+	for _, res := range r.Results {
+		if !res.Passed {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(r)
+}
+
+func (r *Report) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "RESULT\tSCENARIO\tRPC\tPATTERNS\tCALLS\tDURATION\tAVG\tEXPECTED\tOBSERVED")
+
+	for _, res := range r.Results {
+		result := "PASS"
+		if !res.Passed {
+			result = "FAIL"
+		}
+
+		var patterns []string
+		for _, p := range res.Patterns {
+			patterns = append(patterns, string(p))
+		}
+
+		observed := formatObserved(res.Observed)
+		if res.Error != "" {
+			observed = "error: " + res.Error
+		}
+
+		fmt.Fprintf(tw, "%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\n",
+			result, res.Name, res.RPC, strings.Join(patterns, ","), res.Calls,
+			res.Duration.Round(time.Millisecond), res.AverageCall.Round(time.Millisecond),
+			strings.Join(res.Expected, ","), observed,
+		)
+	}
+
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(w, "\n%d scenario(s) in %v\n", len(r.Results), r.Duration.Round(time.Millisecond))
+
+	return err
+}
+
+func formatObserved(observed map[string]int) string {
+	var names []string
+	for name := range observed {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	var parts []string
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%v=%d", name, observed[name]))
+	}
+
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,289 @@
+package scenario
+
+import (
+	"context"
+	"io"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/sony/gobreaker"
+	"github.com/viquitorreis/my-grpc-go-client/internal/interceptor"
+	"github.com/viquitorreis/my-grpc-proto/protogen/go/resiliency"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Dialer opens a connection to the resiliency server with extra options on top of the client defaults.
+type Dialer func(opts ...grpc.DialOption) (*grpc.ClientConn, error)
+
+type Runner struct {
+	dial    Dialer
+	metrics *interceptor.Metrics
+}
+
+// NewRunner reports the breaker and retry events of the scenarios to metrics, which may be nil.
+func NewRunner(dial Dialer, metrics *interceptor.Metrics) *Runner {
+	return &Runner{dial: dial, metrics: metrics}
+}
+
+// Run executes every scenario in order. Each scenario gets its own connection so
+// that the interceptors of its patterns do not leak into the next one.
+func (r *Runner) Run(ctx context.Context, scenarios []Scenario) *Report {
+	report := &Report{StartedAt: time.Now()}
+
+	for _, sc := range scenarios {
+		log.Printf("Running scenario %v (%v)\n", sc.Name, sc.RPC)
+
+		result := r.runScenario(ctx, sc)
+		report.Results = append(report.Results, result)
+	}
+
+	report.Duration = time.Since(report.StartedAt)
+
+	return report
+}
+
+func (r *Runner) runScenario(ctx context.Context, sc Scenario) Result {
+	result := Result{
+		Name:     sc.Name,
+		RPC:      sc.RPC,
+		Patterns: sc.Patterns,
+		Observed: make(map[string]int),
+	}
+
+	for _, c := range sc.Expected() {
+		result.Expected = append(result.Expected, c.String())
+	}
+
+	conn, err := r.dial(r.patternOptions(sc)...)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer conn.Close()
+
+	calls := resiliencyCalls(conn, sc.Uses(PatternMetadata))
+
+	start := time.Now()
+
+	var observed []codes.Code
+	switch sc.RPC {
+	case RPCUnary:
+		observed = runUnary(ctx, calls, sc)
+	case RPCServerStream:
+		observed, result.Messages = runServerStream(ctx, calls, sc)
+	case RPCClientStream:
+		observed = runClientStream(ctx, calls, sc)
+	case RPCBidiStream:
+		observed, result.Messages = runBidiStream(ctx, calls, sc)
+	}
+
+	result.Duration = time.Since(start)
+	result.Calls = len(observed)
+
+	if result.Calls > 0 {
+		result.AverageCall = result.Duration / time.Duration(result.Calls)
+	}
+
+	expected := sc.Expected()
+	result.Passed = len(observed) > 0
+
+	for _, c := range observed {
+		result.Observed[c.String()]++
+
+		if !slices.Contains(expected, c) {
+			result.Passed = false
+		}
+	}
+
+	return result
+}
+
+func (r *Runner) patternOptions(sc Scenario) []grpc.DialOption {
+	var (
+		unary  []grpc.UnaryClientInterceptor
+		stream []grpc.StreamClientInterceptor
+	)
+
+	if sc.Uses(PatternBreaker) {
+		cb := gobreaker.NewCircuitBreaker(gobreaker.Settings{
+			Name: sc.Name,
+			ReadyToTrip: func(counts gobreaker.Counts) bool {
+				failureRatio := float64(counts.TotalFailures) / float64(counts.Requests)
+				return counts.Requests >= 3 && failureRatio >= 0.6
+			},
+			Timeout: 4 * time.Second,
+			OnStateChange: func(name string, from, to gobreaker.State) {
+				log.Printf("Circuit breaker %v changed state, from %v to %v\n", name, from, to)
+
+				if r.metrics != nil {
+					r.metrics.OnStateChange(name, from, to)
+				}
+			},
+		})
+
+		unary = append(unary, interceptor.CircuitBreakerUnaryClientInterceptor(cb))
+		stream = append(stream, interceptor.CircuitBreakerStreamClientInterceptor(cb))
+	}
+
+	if sc.Uses(PatternRetry) {
+		retry := interceptor.RetrySettings{
+			Max:     4,
+			Codes:   []codes.Code{codes.Unknown, codes.Internal},
+			Backoff: interceptor.BackoffExponential(500 * time.Millisecond),
+			OnRetry: func(method string, attempt int, err error) {
+				log.Printf("Retrying %v, attempt %v after: %v\n", method, attempt, err)
+
+				if r.metrics != nil {
+					r.metrics.OnRetry(method, attempt, err)
+				}
+			},
+		}
+
+		unary = append(unary, interceptor.RetryUnaryClientInterceptor(retry))
+		stream = append(stream, interceptor.RetryStreamClientInterceptor(retry))
+	}
+
+	return []grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(unary...),
+		grpc.WithChainStreamInterceptor(stream...),
+	}
+}
+
+// calls points at either ResiliencyService or ResiliencyWithMetadataService, which share their message types.
+type calls struct {
+	unary        func(ctx context.Context, in *resiliency.ResiliencyRequest, opts ...grpc.CallOption) (*resiliency.ResiliencyReponse, error)
+	serverStream func(ctx context.Context, in *resiliency.ResiliencyRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[resiliency.ResiliencyReponse], error)
+	clientStream func(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[resiliency.ResiliencyRequest, resiliency.ResiliencyReponse], error)
+	bidiStream   func(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[resiliency.ResiliencyRequest, resiliency.ResiliencyReponse], error)
+}
+
+func resiliencyCalls(conn *grpc.ClientConn, withMetadata bool) calls {
+	if withMetadata {
+		client := resiliency.NewResiliencyWithMetadataServiceClient(conn)
+		return calls{
+			unary:        client.UnaryResiliencyWithMetadata,
+			serverStream: client.ServerStreamResiliencyWithMetadata,
+			clientStream: client.ClientStreamResiliencyWithMetadata,
+			bidiStream:   client.BidirectionalStreamResiliencyWithMetadata,
+		}
+	}
+
+	client := resiliency.NewResiliencyServiceClient(conn)
+	return calls{
+		unary:        client.UnaryResiliency,
+		serverStream: client.ServerStreamResiliency,
+		clientStream: client.ClientStreamResiliency,
+		bidiStream:   client.BidirectionalStreamResiliency,
+	}
+}
+
+func (c calls) callContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+
+	return context.WithCancel(ctx)
+}
+
+func request(sc Scenario) *resiliency.ResiliencyRequest {
+	return &resiliency.ResiliencyRequest{
+		MinDelaySecond: sc.MinDelaySecond,
+		MaxDelaySecond: sc.MaxDelaySecond,
+		StatusCodes:    sc.StatusCodes,
+	}
+}
+
+// streamCode turns the error that ended a stream into its status code.
+func streamCode(err error) codes.Code {
+	if err == io.EOF {
+		return codes.OK
+	}
+
+	return status.Code(err)
+}
+
+func runUnary(ctx context.Context, c calls, sc Scenario) []codes.Code {
+	var observed []codes.Code
+
+	for i := 0; i < sc.Count; i++ {
+		callCtx, cancel := c.callContext(ctx, sc.Timeout)
+		_, err := c.unary(callCtx, request(sc))
+		cancel()
+
+		observed = append(observed, status.Code(err))
+	}
+
+	return observed
+}
+
+func runServerStream(ctx context.Context, c calls, sc Scenario) ([]codes.Code, int) {
+	callCtx, cancel := c.callContext(ctx, sc.Timeout)
+	defer cancel()
+
+	stream, err := c.serverStream(callCtx, request(sc))
+	if err != nil {
+		return []codes.Code{status.Code(err)}, 0
+	}
+
+	messages := 0
+	for {
+		if _, err := stream.Recv(); err != nil {
+			return []codes.Code{streamCode(err)}, messages
+		}
+
+		messages++
+	}
+}
+
+func runClientStream(ctx context.Context, c calls, sc Scenario) []codes.Code {
+	callCtx, cancel := c.callContext(ctx, sc.Timeout)
+	defer cancel()
+
+	stream, err := c.clientStream(callCtx)
+	if err != nil {
+		return []codes.Code{status.Code(err)}
+	}
+
+	for i := 0; i < sc.Count; i++ {
+		if err := stream.Send(request(sc)); err != nil {
+			// the real status comes with CloseAndRecv
+			break
+		}
+	}
+
+	_, err = stream.CloseAndRecv()
+
+	return []codes.Code{status.Code(err)}
+}
+
+func runBidiStream(ctx context.Context, c calls, sc Scenario) ([]codes.Code, int) {
+	callCtx, cancel := c.callContext(ctx, sc.Timeout)
+	defer cancel()
+
+	stream, err := c.bidiStream(callCtx)
+	if err != nil {
+		return []codes.Code{status.Code(err)}, 0
+	}
+
+	go func() {
+		for i := 0; i < sc.Count; i++ {
+			if err := stream.Send(request(sc)); err != nil {
+				break
+			}
+		}
+
+		stream.CloseSend()
+	}()
+
+	messages := 0
+	for {
+		if _, err := stream.Recv(); err != nil {
+			return []codes.Code{streamCode(err)}, messages
+		}
+
+		messages++
+	}
+}
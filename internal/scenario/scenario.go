@@ -0,0 +1,142 @@
+package scenario
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"gopkg.in/yaml.v3"
+)
+
+type RPC string
+
+const (
+	RPCUnary        RPC = "unary"
+	RPCServerStream RPC = "server_stream"
+	RPCClientStream RPC = "client_stream"
+	RPCBidiStream   RPC = "bidi_stream"
+)
+
+type Pattern string
+
+const (
+	PatternRetry    Pattern = "retry"
+	PatternBreaker  Pattern = "breaker"
+	PatternMetadata Pattern = "metadata"
+)
+
+// Code is a gRPC status code that can be written in YAML as a number or as its name, e.g. DEADLINE_EXCEEDED.
+type Code codes.Code
+
+func (c *Code) UnmarshalYAML(node *yaml.Node) error {
+	var code codes.Code
+
+	b := []byte(node.Value)
+	if node.Tag == "!!str" {
+		b = []byte(`"` + node.Value + `"`)
+	}
+
+	if err := code.UnmarshalJSON(b); err != nil {
+		return fmt.Errorf("line %d: %w", node.Line, err)
+	}
+
+	*c = Code(code)
+
+	return nil
+}
+
+type Scenario struct {
+	Name           string        `yaml:"name"`
+	RPC            RPC           `yaml:"rpc"`
+	MinDelaySecond int32         `yaml:"min_delay_second"`
+	MaxDelaySecond int32         `yaml:"max_delay_second"`
+	StatusCodes    []uint32      `yaml:"status_codes"`
+	Count          int           `yaml:"count"`
+	Timeout        time.Duration `yaml:"timeout"`
+	Patterns       []Pattern     `yaml:"patterns"`
+	// ExpectedCodes defaults to StatusCodes, the codes the server is asked to answer with.
+	ExpectedCodes []Code `yaml:"expected_codes"`
+}
+
+type File struct {
+	Scenarios []Scenario `yaml:"scenarios"`
+}
+
+func (s Scenario) Uses(p Pattern) bool {
+	return slices.Contains(s.Patterns, p)
+}
+
+func (s Scenario) Expected() []codes.Code {
+	var expected []codes.Code
+
+	if len(s.ExpectedCodes) > 0 {
+		for _, c := range s.ExpectedCodes {
+			expected = append(expected, codes.Code(c))
+		}
+
+		return expected
+	}
+
+	for _, c := range s.StatusCodes {
+		expected = append(expected, codes.Code(c))
+	}
+
+	if len(expected) == 0 {
+		expected = append(expected, codes.OK)
+	}
+
+	return expected
+}
+
+func (s Scenario) validate() error {
+	if s.Name == "" {
+		return fmt.Errorf("scenario without name")
+	}
+
+	switch s.RPC {
+	case RPCUnary, RPCServerStream, RPCClientStream, RPCBidiStream:
+	default:
+		return fmt.Errorf("scenario %v: unknown rpc %q", s.Name, s.RPC)
+	}
+
+	for _, p := range s.Patterns {
+		switch p {
+		case PatternRetry, PatternBreaker, PatternMetadata:
+		default:
+			return fmt.Errorf("scenario %v: unknown pattern %q", s.Name, p)
+		}
+	}
+
+	if s.MinDelaySecond > s.MaxDelaySecond {
+		return fmt.Errorf("scenario %v: min_delay_second is greater than max_delay_second", s.Name)
+	}
+
+	return nil
+}
+
+// LoadFile reads and validates the scenarios of one YAML file.
+func LoadFile(path string) ([]Scenario, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var f File
+	if err := yaml.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("%v: %w", path, err)
+	}
+
+	for i := range f.Scenarios {
+		if f.Scenarios[i].Count <= 0 {
+			f.Scenarios[i].Count = 1
+		}
+
+		if err := f.Scenarios[i].validate(); err != nil {
+			return nil, fmt.Errorf("%v: %w", path, err)
+		}
+	}
+
+	return f.Scenarios, nil
+}
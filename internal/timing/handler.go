@@ -0,0 +1,271 @@
+package timing
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/viquitorreis/my-grpc-go-client/internal/metrics"
+	"google.golang.org/grpc/stats"
+	"google.golang.org/grpc/status"
+)
+
+// Breakdown is where the time of a call attempt went. The offsets are relative to Begin and
+// zero when the event did not happen, e.g. no response was received.
+type Breakdown struct {
+	Method string    `json:"method"`
+	Begin  time.Time `json:"begin"`
+	// TransparentRetry is set on attempts grpc retried by itself, before reaching the server.
+	TransparentRetry bool `json:"transparent_retry,omitempty"`
+	// Connected is set when the call waited for a new connection, it is when the connection was ready.
+	Connected time.Duration `json:"connected_ns,omitempty"`
+	// HeaderSent is how long the call waited for a ready transport, resolving and connecting included.
+	HeaderSent     time.Duration `json:"header_sent_ns,omitempty"`
+	HeaderReceived time.Duration `json:"header_received_ns,omitempty"`
+	// FirstByte and LastByte are when the first and last response messages were received.
+	FirstByte time.Duration `json:"first_byte_ns,omitempty"`
+	LastByte  time.Duration `json:"last_byte_ns,omitempty"`
+	End       time.Duration `json:"end_ns"`
+
+	SentMessages     int `json:"sent_messages"`
+	ReceivedMessages int `json:"received_messages"`
+	// Sent and Received count the uncompressed payload bytes, Compressed the payload bytes
+	// after compression and Wire the bytes on the wire, framing and headers included.
+	SentBytes          int `json:"sent_bytes"`
+	SentCompressed     int `json:"sent_compressed_bytes"`
+	SentWire           int `json:"sent_wire_bytes"`
+	ReceivedBytes      int `json:"received_bytes"`
+	ReceivedCompressed int `json:"received_compressed_bytes"`
+	ReceivedWire       int `json:"received_wire_bytes"`
+	// SentCompression and ReceivedCompression are the codecs of the messages, empty when uncompressed.
+	SentCompression     string `json:"sent_compression,omitempty"`
+	ReceivedCompression string `json:"received_compression,omitempty"`
+
+	Code  string `json:"code"`
+	Error string `json:"error,omitempty"`
+}
+
+func (b *Breakdown) attrs() []slog.Attr {
+	attrs := []slog.Attr{
+		slog.String("method", b.Method),
+		slog.String("code", b.Code),
+		slog.Duration("header_sent", b.HeaderSent),
+		slog.Duration("header_received", b.HeaderReceived),
+		slog.Duration("first_byte", b.FirstByte),
+		slog.Duration("last_byte", b.LastByte),
+		slog.Duration("end", b.End),
+		slog.Int("sent_messages", b.SentMessages),
+		slog.Int("received_messages", b.ReceivedMessages),
+		slog.Int("sent_bytes", b.SentBytes),
+		slog.Int("sent_compressed_bytes", b.SentCompressed),
+		slog.Int("sent_wire_bytes", b.SentWire),
+		slog.Int("received_bytes", b.ReceivedBytes),
+		slog.Int("received_compressed_bytes", b.ReceivedCompressed),
+		slog.Int("received_wire_bytes", b.ReceivedWire),
+	}
+
+	if b.Connected > 0 {
+		attrs = append(attrs, slog.Duration("connected", b.Connected))
+	}
+
+	if b.SentCompression != "" {
+		attrs = append(attrs, slog.String("sent_compression", b.SentCompression))
+	}
+
+	if b.ReceivedCompression != "" {
+		attrs = append(attrs, slog.String("received_compression", b.ReceivedCompression))
+	}
+
+	if b.TransparentRetry {
+		attrs = append(attrs, slog.Bool("transparent_retry", true))
+	}
+
+	if b.Error != "" {
+		attrs = append(attrs, slog.String("error", b.Error))
+	}
+
+	return attrs
+}
+
+type Settings struct {
+	// Logger receives a "grpc call timing" record per attempt, slog.Default() when nil.
+	Logger *slog.Logger
+	Level  slog.Level
+	// Registry receives the breakdown metrics when set.
+	Registry *metrics.Registry
+	// History is how many breakdowns Recent can return, 100 by default.
+	History int
+}
+
+// Handler is a grpc stats.Handler recording the Breakdown of every call attempt of the
+// connections it is registered on with grpc.WithStatsHandler.
+type Handler struct {
+	settings Settings
+	metrics  *handlerMetrics
+
+	mu      sync.Mutex
+	history []Breakdown
+	next    int
+	full    bool
+	conns   map[string]time.Time
+}
+
+func NewHandler(settings Settings) *Handler {
+	if settings.History <= 0 {
+		settings.History = 100
+	}
+
+	h := &Handler{
+		settings: settings,
+		history:  make([]Breakdown, settings.History),
+		conns:    make(map[string]time.Time),
+	}
+
+	if settings.Registry != nil {
+		h.metrics = newHandlerMetrics(settings.Registry)
+	}
+
+	return h
+}
+
+// Recent returns up to the last n breakdowns, the most recent first.
+func (h *Handler) Recent(n int) []Breakdown {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	size := h.next
+	if h.full {
+		size = len(h.history)
+	}
+
+	n = min(n, size)
+
+	recent := make([]Breakdown, 0, n)
+	for i := 1; i <= n; i++ {
+		recent = append(recent, h.history[(h.next-i+len(h.history))%len(h.history)])
+	}
+
+	return recent
+}
+
+func (h *Handler) record(b Breakdown) {
+	h.mu.Lock()
+	h.history[h.next] = b
+	h.next = (h.next + 1) % len(h.history)
+	h.full = h.full || h.next == 0
+	h.mu.Unlock()
+
+	if h.metrics != nil {
+		h.metrics.observe(&b)
+	}
+
+	logger := h.settings.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	logger.LogAttrs(context.Background(), h.settings.Level, "grpc call timing", b.attrs()...)
+}
+
+type connKey struct{}
+
+type rpcKey struct{}
+
+type rpcState struct {
+	mu sync.Mutex
+	b  Breakdown
+}
+
+func (h *Handler) TagConn(ctx context.Context, info *stats.ConnTagInfo) context.Context {
+	return context.WithValue(ctx, connKey{}, info.RemoteAddr.String())
+}
+
+// HandleConn remembers when the connections to each address were ready, to tell the calls
+// that had to wait for one.
+func (h *Handler) HandleConn(ctx context.Context, s stats.ConnStats) {
+	addr, _ := ctx.Value(connKey{}).(string)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	switch s.(type) {
+	case *stats.ConnBegin:
+		h.conns[addr] = time.Now()
+	case *stats.ConnEnd:
+		delete(h.conns, addr)
+	}
+}
+
+func (h *Handler) TagRPC(ctx context.Context, info *stats.RPCTagInfo) context.Context {
+	return context.WithValue(ctx, rpcKey{}, &rpcState{b: Breakdown{Method: info.FullMethodName}})
+}
+
+func (h *Handler) HandleRPC(ctx context.Context, s stats.RPCStats) {
+	state, ok := ctx.Value(rpcKey{}).(*rpcState)
+	if !ok || !s.IsClient() {
+		return
+	}
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	b := &state.b
+
+	switch s := s.(type) {
+	case *stats.Begin:
+		b.Begin = s.BeginTime
+		b.TransparentRetry = s.IsTransparentRetryAttempt
+	case *stats.OutHeader:
+		b.HeaderSent = time.Since(b.Begin)
+		b.SentCompression = s.Compression
+
+		if s.RemoteAddr != nil {
+			h.mu.Lock()
+			connected, ok := h.conns[s.RemoteAddr.String()]
+			h.mu.Unlock()
+
+			if ok && connected.After(b.Begin) {
+				b.Connected = connected.Sub(b.Begin)
+			}
+		}
+	case *stats.OutPayload:
+		b.SentMessages++
+		b.SentBytes += s.Length
+		b.SentCompressed += s.CompressedLength
+		b.SentWire += s.WireLength
+
+		if h.metrics != nil && b.SentCompression != "" {
+			h.metrics.observeRatio(b.Method, "sent", b.SentCompression, s.Length, s.CompressedLength)
+		}
+	case *stats.InHeader:
+		b.HeaderReceived = time.Since(b.Begin)
+		b.ReceivedCompression = s.Compression
+		b.ReceivedWire += s.WireLength
+	case *stats.InPayload:
+		if b.ReceivedMessages == 0 {
+			b.FirstByte = s.RecvTime.Sub(b.Begin)
+		}
+
+		b.LastByte = s.RecvTime.Sub(b.Begin)
+		b.ReceivedMessages++
+		b.ReceivedBytes += s.Length
+		b.ReceivedCompressed += s.CompressedLength
+		b.ReceivedWire += s.WireLength
+
+		if h.metrics != nil && b.ReceivedCompression != "" {
+			h.metrics.observeRatio(b.Method, "received", b.ReceivedCompression, s.Length, s.CompressedLength)
+		}
+	case *stats.InTrailer:
+		b.ReceivedWire += s.WireLength
+	case *stats.End:
+		b.End = s.EndTime.Sub(b.Begin)
+		b.Code = status.Code(s.Error).String()
+
+		if s.Error != nil {
+			b.Error = s.Error.Error()
+		}
+
+		h.record(*b)
+	}
+}
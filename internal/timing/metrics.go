@@ -0,0 +1,81 @@
+package timing
+
+import (
+	"strings"
+
+	"github.com/viquitorreis/my-grpc-go-client/internal/metrics"
+)
+
+type handlerMetrics struct {
+	headerSent *metrics.Histogram
+	firstByte  *metrics.Histogram
+	lastByte   *metrics.Histogram
+	connected  *metrics.Histogram
+	sentBytes  *metrics.Counter
+	recvBytes  *metrics.Counter
+	ratio      *metrics.Histogram
+}
+
+// ratioBuckets are compressed over uncompressed sizes, above 1 when compression did not pay.
+var ratioBuckets = []float64{0.05, 0.1, 0.2, 0.3, 0.4, 0.5, 0.6, 0.7, 0.8, 0.9, 1, 1.2}
+
+func newHandlerMetrics(reg *metrics.Registry) *handlerMetrics {
+	return &handlerMetrics{
+		headerSent: reg.NewHistogram("grpc_client_attempt_header_sent_seconds",
+			"Time from the start of an attempt until its headers were sent.", metrics.DefaultBuckets, "grpc_service", "grpc_method"),
+		firstByte: reg.NewHistogram("grpc_client_attempt_first_byte_seconds",
+			"Time from the start of an attempt until its first response message.", metrics.DefaultBuckets, "grpc_service", "grpc_method"),
+		lastByte: reg.NewHistogram("grpc_client_attempt_last_byte_seconds",
+			"Time from the start of an attempt until its last response message.", metrics.DefaultBuckets, "grpc_service", "grpc_method"),
+		connected: reg.NewHistogram("grpc_client_attempt_connect_wait_seconds",
+			"Time attempts waited for a new connection.", metrics.DefaultBuckets, "grpc_service", "grpc_method"),
+		sentBytes: reg.NewCounter("grpc_client_sent_bytes_total",
+			"Bytes sent by the client, by kind: payload, compressed or wire.", "grpc_service", "grpc_method", "kind"),
+		recvBytes: reg.NewCounter("grpc_client_received_bytes_total",
+			"Bytes received by the client, by kind: payload, compressed or wire.", "grpc_service", "grpc_method", "kind"),
+		ratio: reg.NewHistogram("grpc_client_compression_ratio",
+			"Compressed over uncompressed size of the compressed messages.", ratioBuckets, "grpc_service", "grpc_method", "direction", "codec"),
+	}
+}
+
+func splitMethod(fullMethod string) (string, string) {
+	service, method, ok := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	if !ok {
+		return "unknown", fullMethod
+	}
+
+	return service, method
+}
+
+func (m *handlerMetrics) observeRatio(fullMethod, direction, codec string, length, compressed int) {
+	if length == 0 {
+		return
+	}
+
+	service, method := splitMethod(fullMethod)
+	m.ratio.Observe(float64(compressed)/float64(length), service, method, direction, codec)
+}
+
+func (m *handlerMetrics) observe(b *Breakdown) {
+	service, method := splitMethod(b.Method)
+
+	if b.HeaderSent > 0 {
+		m.headerSent.Observe(b.HeaderSent.Seconds(), service, method)
+	}
+
+	if b.ReceivedMessages > 0 {
+		m.firstByte.Observe(b.FirstByte.Seconds(), service, method)
+		m.lastByte.Observe(b.LastByte.Seconds(), service, method)
+	}
+
+	if b.Connected > 0 {
+		m.connected.Observe(b.Connected.Seconds(), service, method)
+	}
+
+	m.sentBytes.Add(float64(b.SentBytes), service, method, "payload")
+	m.sentBytes.Add(float64(b.SentCompressed), service, method, "compressed")
+	m.sentBytes.Add(float64(b.SentWire), service, method, "wire")
+	m.recvBytes.Add(float64(b.ReceivedBytes), service, method, "payload")
+	m.recvBytes.Add(float64(b.ReceivedCompressed), service, method, "compressed")
+	m.recvBytes.Add(float64(b.ReceivedWire), service, method, "wire")
+}
@@ -0,0 +1,267 @@
+package tlsconfig
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/credentials"
+)
+
+type Settings struct {
+	// CAFile is a PEM bundle of the CAs trusted for the server, the system pool when empty.
+	CAFile string
+	// CertFile and KeyFile are the client certificate and key for mTLS.
+	CertFile string
+	KeyFile  string
+	// ServerName overrides the name checked against the server certificate and sent as SNI.
+	ServerName string
+	MinVersion uint16
+	// URISANs pins the server identity to one of these URI SANs, e.g.
+	// spiffe://bank.example/server. The server name is not checked when set.
+	URISANs []string
+}
+
+// ParseVersion parses the TLS versions used in configs, e.g. 1.2.
+func ParseVersion(v string) (uint16, error) {
+	switch v {
+	case "", "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	}
+
+	return 0, fmt.Errorf("unsupported TLS version %q, expected 1.2 or 1.3", v)
+}
+
+// Reloader keeps the certificates of Settings up to date with the files on disk. Every new
+// handshake uses the current files, established connections and their streams are left alone.
+type Reloader struct {
+	settings Settings
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	roots   *x509.CertPool
+	modTime map[string]time.Time
+}
+
+func New(settings Settings) (*Reloader, error) {
+	if (settings.CertFile == "") != (settings.KeyFile == "") {
+		return nil, errors.New("tls needs both a certificate and a key for mTLS")
+	}
+
+	if settings.MinVersion == 0 {
+		settings.MinVersion = tls.VersionTLS12
+	}
+
+	r := &Reloader{settings: settings, modTime: make(map[string]time.Time)}
+
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// changed reports whether one of the files was modified since the last load, r.mu must be held.
+func (r *Reloader) changed(paths ...string) (bool, error) {
+	changed := false
+
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return false, err
+		}
+
+		if !info.ModTime().Equal(r.modTime[path]) {
+			changed = true
+		}
+	}
+
+	return changed, nil
+}
+
+func (r *Reloader) touch(paths ...string) {
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil {
+			r.modTime[path] = info.ModTime()
+		}
+	}
+}
+
+// reload loads the files that changed, keeping the previous certificates when they are invalid.
+func (r *Reloader) reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if s := r.settings; s.CAFile != "" {
+		changed, err := r.changed(s.CAFile)
+		if err != nil {
+			return err
+		}
+
+		if changed {
+			pem, err := os.ReadFile(s.CAFile)
+			if err != nil {
+				return err
+			}
+
+			roots := x509.NewCertPool()
+			if !roots.AppendCertsFromPEM(pem) {
+				return fmt.Errorf("no certificates in CA bundle %v", s.CAFile)
+			}
+
+			if r.roots != nil {
+				log.Println("Reloaded CA bundle", s.CAFile)
+			}
+
+			r.roots = roots
+			r.touch(s.CAFile)
+		}
+	}
+
+	if s := r.settings; s.CertFile != "" {
+		changed, err := r.changed(s.CertFile, s.KeyFile)
+		if err != nil {
+			return err
+		}
+
+		if changed {
+			cert, err := tls.LoadX509KeyPair(s.CertFile, s.KeyFile)
+			if err != nil {
+				return err
+			}
+
+			if r.cert != nil {
+				log.Println("Reloaded client certificate", s.CertFile)
+			}
+
+			r.cert = &cert
+			r.touch(s.CertFile, s.KeyFile)
+		}
+	}
+
+	return nil
+}
+
+func (r *Reloader) current() (*tls.Certificate, *x509.CertPool) {
+	// a certificate being rewritten fails to load until both files are complete
+	if err := r.reload(); err != nil {
+		log.Println("Failed to reload TLS certificates, keeping the previous ones: ", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.cert, r.roots
+}
+
+// Config returns the tls.Config of a client dialing serverName, Settings.ServerName when set.
+// The server chain is verified in VerifyConnection, against the CA bundle loaded at handshake
+// time rather than the fixed RootCAs of tls.Config, and against serverName, which may be an IP,
+// rather than the SNI name of the connection, which is empty for IPs.
+func (r *Reloader) Config(serverName string) *tls.Config {
+	if r.settings.ServerName != "" {
+		serverName = r.settings.ServerName
+	}
+
+	return &tls.Config{
+		ServerName: serverName,
+		MinVersion: r.settings.MinVersion,
+		// verification is not skipped but done by verify, see above
+		InsecureSkipVerify: true,
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			cert, _ := r.current()
+			if cert == nil {
+				return &tls.Certificate{}, nil
+			}
+
+			return cert, nil
+		},
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			return r.verify(cs, serverName)
+		},
+	}
+}
+
+func (r *Reloader) verify(cs tls.ConnectionState, serverName string) error {
+	if len(cs.PeerCertificates) == 0 {
+		return errors.New("tls: server sent no certificate")
+	}
+
+	if serverName == "" && len(r.settings.URISANs) == 0 {
+		return errors.New("tls: no server name to verify the server certificate against")
+	}
+
+	_, roots := r.current()
+
+	opts := x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: x509.NewCertPool(),
+	}
+
+	for _, cert := range cs.PeerCertificates[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+
+	if len(r.settings.URISANs) == 0 {
+		opts.DNSName = serverName
+	}
+
+	leaf := cs.PeerCertificates[0]
+	if _, err := leaf.Verify(opts); err != nil {
+		return err
+	}
+
+	if len(r.settings.URISANs) == 0 {
+		return nil
+	}
+
+	for _, uri := range leaf.URIs {
+		if slices.Contains(r.settings.URISANs, uri.String()) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("tls: server certificate URI SANs %v do not match the pinned %v", leaf.URIs, r.settings.URISANs)
+}
+
+// Credentials returns the grpc transport credentials of the reloader.
+func (r *Reloader) Credentials() credentials.TransportCredentials {
+	return &transportCredentials{
+		TransportCredentials: credentials.NewTLS(r.Config("")),
+		reloader:             r,
+	}
+}
+
+// transportCredentials verifies every connection against the host of the authority grpc
+// dials, the way credentials.NewTLS picks the SNI name, unless Settings.ServerName is set.
+type transportCredentials struct {
+	credentials.TransportCredentials
+
+	reloader *Reloader
+}
+
+func (c *transportCredentials) ClientHandshake(ctx context.Context, authority string, conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	host, _, err := net.SplitHostPort(authority)
+	if err != nil {
+		host = authority
+	}
+
+	return credentials.NewTLS(c.reloader.Config(host)).ClientHandshake(ctx, authority, conn)
+}
+
+func (c *transportCredentials) Clone() credentials.TransportCredentials {
+	return &transportCredentials{
+		TransportCredentials: c.TransportCredentials.Clone(),
+		reloader:             c.reloader,
+	}
+}
@@ -0,0 +1,375 @@
+package tlsconfig
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+var serial atomic.Int64
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+type leafSpec struct {
+	cn   string
+	dns  []string
+	ips  []net.IP
+	uris []string
+}
+
+func newCA(t *testing.T, name string) *testCA {
+	t.Helper()
+
+	cert, key, certPEM, _ := issue(t, nil, leafSpec{cn: name}, true)
+
+	return &testCA{cert: cert, key: key, pem: certPEM}
+}
+
+// issue creates a certificate signed by ca, self-signed when ca is nil, and returns it with
+// its key, both in PEM too.
+func issue(t *testing.T, ca *testCA, spec leafSpec, isCA bool) (*x509.Certificate, *ecdsa.PrivateKey, []byte, []byte) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial.Add(1)),
+		Subject:               pkix.Name{CommonName: spec.cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		DNSNames:              spec.dns,
+		IPAddresses:           spec.ips,
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+
+	for _, uri := range spec.uris {
+		u, err := url.Parse(uri)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		tmpl.URIs = append(tmpl.URIs, u)
+	}
+
+	parent, parentKey := tmpl, key
+	if ca != nil {
+		parent, parentKey = ca.cert, ca.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return cert, key,
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// writeFile writes b to path with a new modification time, so the reloader sees the change
+// even within the timestamp resolution of the file system.
+func writeFile(t *testing.T, path string, b []byte) {
+	t.Helper()
+
+	if err := os.WriteFile(path, b, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	mtime := time.Now().Add(time.Duration(serial.Add(1)) * time.Second)
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// startServer serves an echo of every message on an unknown method, with the common name of
+// the client certificate in the client-cn header. clientCA requires client certificates.
+func startServer(t *testing.T, ca *testCA, spec leafSpec, clientCA *testCA) string {
+	t.Helper()
+
+	_, _, certPEM, keyPEM := issue(t, ca, spec, false)
+
+	pair, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &tls.Config{Certificates: []tls.Certificate{pair}}
+	if clientCA != nil {
+		cfg.ClientCAs = x509.NewCertPool()
+		cfg.ClientCAs.AddCert(clientCA.cert)
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := grpc.NewServer(grpc.Creds(credentials.NewTLS(cfg)), grpc.UnknownServiceHandler(func(_ any, stream grpc.ServerStream) error {
+		cn := ""
+		if p, ok := peer.FromContext(stream.Context()); ok {
+			if info, ok := p.AuthInfo.(credentials.TLSInfo); ok && len(info.State.PeerCertificates) > 0 {
+				cn = info.State.PeerCertificates[0].Subject.CommonName
+			}
+		}
+
+		if err := stream.SendHeader(metadata.Pairs("client-cn", cn)); err != nil {
+			return err
+		}
+
+		for {
+			var msg durationpb.Duration
+			if err := stream.RecvMsg(&msg); err != nil {
+				return nil
+			}
+
+			if err := stream.SendMsg(&msg); err != nil {
+				return err
+			}
+		}
+	}))
+
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	return lis.Addr().String()
+}
+
+// call makes a unary call to addr with the credentials of r and returns the client-cn header.
+func call(t *testing.T, r *Reloader, addr string) (string, error) {
+	t.Helper()
+
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(r.Credentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var header metadata.MD
+	err = conn.Invoke(ctx, "/test.Echo/Unary", durationpb.New(time.Second), &durationpb.Duration{}, grpc.Header(&header))
+
+	return strings.Join(header.Get("client-cn"), ","), err
+}
+
+type files struct {
+	ca, cert, key string
+}
+
+func newFiles(t *testing.T) files {
+	dir := t.TempDir()
+
+	return files{
+		ca:   filepath.Join(dir, "ca.pem"),
+		cert: filepath.Join(dir, "client.pem"),
+		key:  filepath.Join(dir, "client-key.pem"),
+	}
+}
+
+func newReloader(t *testing.T, settings Settings) *Reloader {
+	t.Helper()
+
+	r, err := New(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return r
+}
+
+func TestHandshake(t *testing.T) {
+	serverCA, otherCA := newCA(t, "server-ca"), newCA(t, "other-ca")
+	addr := startServer(t, serverCA, leafSpec{cn: "server", dns: []string{"bank.internal"}}, nil)
+	f := newFiles(t)
+
+	writeFile(t, f.ca, serverCA.pem)
+
+	if _, err := call(t, newReloader(t, Settings{CAFile: f.ca, ServerName: "bank.internal"}), addr); err != nil {
+		t.Fatalf("call with the server CA: %v", err)
+	}
+
+	if _, err := call(t, newReloader(t, Settings{CAFile: f.ca, ServerName: "evil.internal"}), addr); err == nil {
+		t.Fatal("call with a server name missing from the certificate succeeded")
+	}
+
+	writeFile(t, f.ca, otherCA.pem)
+
+	if _, err := call(t, newReloader(t, Settings{CAFile: f.ca, ServerName: "bank.internal"}), addr); err == nil {
+		t.Fatal("call with an unrelated CA succeeded")
+	}
+}
+
+func TestMutualTLS(t *testing.T) {
+	serverCA, clientCA := newCA(t, "server-ca"), newCA(t, "client-ca")
+	addr := startServer(t, serverCA, leafSpec{cn: "server", dns: []string{"bank.internal"}}, clientCA)
+	f := newFiles(t)
+
+	_, _, certPEM, keyPEM := issue(t, clientCA, leafSpec{cn: "client-1"}, false)
+	writeFile(t, f.ca, serverCA.pem)
+	writeFile(t, f.cert, certPEM)
+	writeFile(t, f.key, keyPEM)
+
+	if _, err := call(t, newReloader(t, Settings{CAFile: f.ca, ServerName: "bank.internal"}), addr); err == nil {
+		t.Fatal("call without a client certificate succeeded")
+	}
+
+	cn, err := call(t, newReloader(t, Settings{CAFile: f.ca, CertFile: f.cert, KeyFile: f.key, ServerName: "bank.internal"}), addr)
+	if err != nil {
+		t.Fatalf("call with a client certificate: %v", err)
+	}
+
+	if cn != "client-1" {
+		t.Fatalf("server saw client %q, want client-1", cn)
+	}
+}
+
+func TestReloadKeepsOpenStreams(t *testing.T) {
+	serverCA, clientCA := newCA(t, "server-ca"), newCA(t, "client-ca")
+	addr := startServer(t, serverCA, leafSpec{cn: "server", dns: []string{"bank.internal"}}, clientCA)
+	f := newFiles(t)
+
+	_, _, certPEM, keyPEM := issue(t, clientCA, leafSpec{cn: "client-1"}, false)
+	writeFile(t, f.ca, serverCA.pem)
+	writeFile(t, f.cert, certPEM)
+	writeFile(t, f.key, keyPEM)
+
+	r := newReloader(t, Settings{CAFile: f.ca, CertFile: f.cert, KeyFile: f.key, ServerName: "bank.internal"})
+
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(r.Credentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := conn.NewStream(ctx, &grpc.StreamDesc{ClientStreams: true, ServerStreams: true}, "/test.Echo/Bidi")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	echo := func() error {
+		if err := stream.SendMsg(durationpb.New(time.Second)); err != nil {
+			return err
+		}
+
+		return stream.RecvMsg(&durationpb.Duration{})
+	}
+
+	if err := echo(); err != nil {
+		t.Fatalf("stream before the reload: %v", err)
+	}
+
+	_, _, certPEM, keyPEM = issue(t, clientCA, leafSpec{cn: "client-2"}, false)
+	writeFile(t, f.cert, certPEM)
+	writeFile(t, f.key, keyPEM)
+
+	cn, err := call(t, r, addr)
+	if err != nil {
+		t.Fatalf("call after the reload: %v", err)
+	}
+
+	if cn != "client-2" {
+		t.Fatalf("new connection presented %q, want the reloaded client-2", cn)
+	}
+
+	if err := echo(); err != nil {
+		t.Fatalf("stream opened before the reload: %v", err)
+	}
+
+	header, err := stream.Header()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := header.Get("client-cn"); len(got) != 1 || got[0] != "client-1" {
+		t.Fatalf("open stream is on the connection of %v, want client-1", got)
+	}
+
+	// a key that does not load keeps the previous certificate
+	writeFile(t, f.key, []byte("not a key"))
+
+	if cn, err := call(t, r, addr); err != nil || cn != "client-2" {
+		t.Fatalf("call with a broken key file = %q, %v, want client-2", cn, err)
+	}
+}
+
+func TestURISANPinning(t *testing.T) {
+	serverCA := newCA(t, "server-ca")
+	addr := startServer(t, serverCA, leafSpec{cn: "server", uris: []string{"spiffe://bank.example/server"}}, nil)
+	f := newFiles(t)
+
+	writeFile(t, f.ca, serverCA.pem)
+
+	pinned := newReloader(t, Settings{CAFile: f.ca, URISANs: []string{"spiffe://bank.example/other", "spiffe://bank.example/server"}})
+	if _, err := call(t, pinned, addr); err != nil {
+		t.Fatalf("call with a matching pin: %v", err)
+	}
+
+	mismatched := newReloader(t, Settings{CAFile: f.ca, URISANs: []string{"spiffe://bank.example/other"}})
+	if _, err := call(t, mismatched, addr); err == nil || !strings.Contains(err.Error(), "do not match the pinned") {
+		t.Fatalf("call with a mismatched pin = %v, want a pinning error", err)
+	}
+}
+
+func TestIPTargetChecksIPSANs(t *testing.T) {
+	serverCA := newCA(t, "server-ca")
+	f := newFiles(t)
+
+	writeFile(t, f.ca, serverCA.pem)
+
+	r := newReloader(t, Settings{CAFile: f.ca})
+
+	evil := startServer(t, serverCA, leafSpec{cn: "evil", dns: []string{"evil.example"}}, nil)
+	if _, err := call(t, r, evil); err == nil {
+		t.Fatal("call to an IP target accepted a certificate without IP SANs")
+	}
+
+	good := startServer(t, serverCA, leafSpec{cn: "server", ips: []net.IP{net.ParseIP("127.0.0.1")}}, nil)
+	if _, err := call(t, r, good); err != nil {
+		t.Fatalf("call to an IP target with a matching IP SAN: %v", err)
+	}
+}
@@ -0,0 +1,140 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+type TraceID [16]byte
+
+func (t TraceID) String() string { return hex.EncodeToString(t[:]) }
+
+func (t TraceID) IsValid() bool { return t != TraceID{} }
+
+type SpanID [8]byte
+
+func (s SpanID) String() string { return hex.EncodeToString(s[:]) }
+
+func (s SpanID) IsValid() bool { return s != SpanID{} }
+
+// FlagSampled is the sampled bit of the trace flags.
+const FlagSampled byte = 0x01
+
+// SpanContext identifies a span across processes, as carried by the W3C trace context headers.
+type SpanContext struct {
+	TraceID    TraceID
+	SpanID     SpanID
+	Flags      byte
+	TraceState string
+	// Remote is set on contexts parsed from incoming metadata.
+	Remote bool
+}
+
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+func (sc SpanContext) Sampled() bool {
+	return sc.Flags&FlagSampled != 0
+}
+
+// Traceparent formats the span context as a version 00 traceparent value.
+func (sc SpanContext) Traceparent() string {
+	return fmt.Sprintf("00-%v-%v-%02x", sc.TraceID, sc.SpanID, sc.Flags)
+}
+
+// ParseTraceparent parses a traceparent value, see https://www.w3.org/TR/trace-context/#traceparent-header.
+func ParseTraceparent(value string) (SpanContext, error) {
+	var sc SpanContext
+
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return sc, fmt.Errorf("invalid traceparent %q", value)
+	}
+
+	// newer versions may append fields, version 00 has exactly four
+	if parts[0] == "00" && len(parts) != 4 {
+		return sc, fmt.Errorf("invalid traceparent %q", value)
+	}
+
+	if err := decodeHex(sc.TraceID[:], parts[1]); err != nil {
+		return sc, fmt.Errorf("invalid traceparent trace id: %w", err)
+	}
+
+	if err := decodeHex(sc.SpanID[:], parts[2]); err != nil {
+		return sc, fmt.Errorf("invalid traceparent parent id: %w", err)
+	}
+
+	var flags [1]byte
+	if err := decodeHex(flags[:], parts[3]); err != nil {
+		return sc, fmt.Errorf("invalid traceparent flags: %w", err)
+	}
+
+	sc.Flags = flags[0]
+
+	if !sc.IsValid() {
+		return sc, fmt.Errorf("invalid traceparent %q: all-zero id", value)
+	}
+
+	return sc, nil
+}
+
+func decodeHex(dst []byte, s string) error {
+	if len(s) != hex.EncodedLen(len(dst)) || strings.ToLower(s) != s {
+		return fmt.Errorf("%q is not %d lowercase hex digits", s, hex.EncodedLen(len(dst)))
+	}
+
+	_, err := hex.Decode(dst, []byte(s))
+
+	return err
+}
+
+func newTraceID() TraceID {
+	var t TraceID
+	rand.Read(t[:])
+
+	return t
+}
+
+func newSpanID() SpanID {
+	var s SpanID
+	rand.Read(s[:])
+
+	return s
+}
+
+type spanKey struct{}
+
+type remoteKey struct{}
+
+// ContextWithSpan makes span the parent of the spans started with the returned context.
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// SpanFromContext returns the span running with ctx, nil if there is none.
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// ContextWithRemoteParent makes a span context received from another process the parent of
+// the spans started with the returned context.
+func ContextWithRemoteParent(ctx context.Context, sc SpanContext) context.Context {
+	sc.Remote = true
+	return context.WithValue(ctx, remoteKey{}, sc)
+}
+
+// SpanContextFromContext returns the context of the span running with ctx, or the remote parent.
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	if span := SpanFromContext(ctx); span != nil {
+		return span.SpanContext()
+	}
+
+	sc, _ := ctx.Value(remoteKey{}).(SpanContext)
+
+	return sc
+}
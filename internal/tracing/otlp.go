@@ -0,0 +1,242 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+type OTLPSettings struct {
+	// Endpoint is the full traces URL, e.g. http://localhost:4318/v1/traces.
+	Endpoint string
+	Headers  map[string]string
+	Timeout  time.Duration
+}
+
+// OTLPExporter posts spans to an OTLP/HTTP collector using the JSON encoding of the protocol.
+type OTLPExporter struct {
+	settings OTLPSettings
+	client   *http.Client
+}
+
+func NewOTLPExporter(settings OTLPSettings) *OTLPExporter {
+	if settings.Timeout <= 0 {
+		settings.Timeout = 10 * time.Second
+	}
+
+	return &OTLPExporter{
+		settings: settings,
+		client:   &http.Client{Timeout: settings.Timeout},
+	}
+}
+
+func (e *OTLPExporter) Export(ctx context.Context, spans []*SpanData) error {
+	body, err := json.Marshal(otlpRequest(spans))
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.settings.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.settings.Headers {
+		req.Header.Set(k, v)
+	}
+
+	res, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return fmt.Errorf("otlp collector answered %v: %s", res.Status, bytes.TrimSpace(msg))
+	}
+
+	return nil
+}
+
+func (e *OTLPExporter) Shutdown(context.Context) error {
+	e.client.CloseIdleConnections()
+	return nil
+}
+
+// The types below follow the JSON mapping of opentelemetry/proto/collector/trace/v1.
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+type otlpEvent struct {
+	Name         string         `json:"name"`
+	TimeUnixNano string         `json:"timeUnixNano"`
+	Attributes   []otlpKeyValue `json:"attributes,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	TraceState        string         `json:"traceState,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Events            []otlpEvent    `json:"events,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpScopeSpans struct {
+	Scope struct {
+		Name string `json:"name"`
+	} `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpResourceSpans struct {
+	Resource struct {
+		Attributes []otlpKeyValue `json:"attributes"`
+	} `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpExportRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+func otlpRequest(spans []*SpanData) otlpExportRequest {
+	var req otlpExportRequest
+
+	byService := make(map[string]int)
+
+	for _, span := range spans {
+		i, ok := byService[span.Service]
+		if !ok {
+			var rs otlpResourceSpans
+			rs.Resource.Attributes = otlpAttributes(map[string]any{"service.name": span.Service})
+			rs.ScopeSpans = make([]otlpScopeSpans, 1)
+			rs.ScopeSpans[0].Scope.Name = "github.com/viquitorreis/my-grpc-go-client/internal/tracing"
+
+			i = len(req.ResourceSpans)
+			byService[span.Service] = i
+			req.ResourceSpans = append(req.ResourceSpans, rs)
+		}
+
+		scope := &req.ResourceSpans[i].ScopeSpans[0]
+		scope.Spans = append(scope.Spans, otlpSpanOf(span))
+	}
+
+	return req
+}
+
+func otlpSpanOf(span *SpanData) otlpSpan {
+	s := otlpSpan{
+		TraceID:           span.TraceID,
+		SpanID:            span.SpanID,
+		ParentSpanID:      span.ParentID,
+		TraceState:        span.TraceState,
+		Name:              span.Name,
+		Kind:              otlpKind(span.Kind),
+		StartTimeUnixNano: unixNano(span.Start),
+		EndTimeUnixNano:   unixNano(span.End),
+		Attributes:        otlpAttributes(span.Attributes),
+		Status:            otlpStatus{Message: span.Message},
+	}
+
+	switch span.Status {
+	case StatusOK:
+		s.Status.Code = 1
+	case StatusError:
+		s.Status.Code = 2
+	}
+
+	for _, event := range span.Events {
+		s.Events = append(s.Events, otlpEvent{
+			Name:         event.Name,
+			TimeUnixNano: unixNano(event.Time),
+			Attributes:   otlpAttributes(event.Attributes),
+		})
+	}
+
+	return s
+}
+
+func otlpKind(kind SpanKind) int {
+	switch kind {
+	case SpanKindInternal:
+		return 1
+	case SpanKindClient:
+		return 3
+	}
+
+	return 0
+}
+
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+func otlpAttributes(attrs map[string]any) []otlpKeyValue {
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	var kvs []otlpKeyValue
+
+	for _, k := range keys {
+		v := attrs[k]
+		var value otlpAnyValue
+
+		switch v := v.(type) {
+		case string:
+			value.StringValue = &v
+		case bool:
+			value.BoolValue = &v
+		case int:
+			s := strconv.Itoa(v)
+			value.IntValue = &s
+		case int64:
+			s := strconv.FormatInt(v, 10)
+			value.IntValue = &s
+		case uint32:
+			s := strconv.FormatUint(uint64(v), 10)
+			value.IntValue = &s
+		case float64:
+			value.DoubleValue = &v
+		default:
+			s := fmt.Sprint(v)
+			value.StringValue = &s
+		}
+
+		kvs = append(kvs, otlpKeyValue{Key: k, Value: value})
+	}
+
+	return kvs
+}
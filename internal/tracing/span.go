@@ -0,0 +1,135 @@
+package tracing
+
+import (
+	"sync"
+	"time"
+)
+
+type SpanKind string
+
+const (
+	SpanKindInternal SpanKind = "internal"
+	SpanKindClient   SpanKind = "client"
+)
+
+type StatusCode string
+
+const (
+	StatusUnset StatusCode = "unset"
+	StatusOK    StatusCode = "ok"
+	StatusError StatusCode = "error"
+)
+
+type Event struct {
+	Name       string         `json:"name"`
+	Time       time.Time      `json:"time"`
+	Attributes map[string]any `json:"attributes,omitempty"`
+}
+
+// SpanData is what exporters receive of an ended span.
+type SpanData struct {
+	Name       string         `json:"name"`
+	Kind       SpanKind       `json:"kind"`
+	TraceID    string         `json:"trace_id"`
+	SpanID     string         `json:"span_id"`
+	ParentID   string         `json:"parent_span_id,omitempty"`
+	TraceState string         `json:"trace_state,omitempty"`
+	Start      time.Time      `json:"start"`
+	End        time.Time      `json:"end"`
+	Attributes map[string]any `json:"attributes,omitempty"`
+	Events     []Event        `json:"events,omitempty"`
+	Status     StatusCode     `json:"status"`
+	Message    string         `json:"status_message,omitempty"`
+	// Service is the name of the process that produced the span.
+	Service string `json:"service"`
+}
+
+// Span is an operation in progress. Its methods are safe for concurrent use and do nothing
+// once the span has ended.
+type Span struct {
+	tracer *Tracer
+	sc     SpanContext
+
+	mu    sync.Mutex
+	data  SpanData
+	ended bool
+}
+
+func (s *Span) SpanContext() SpanContext {
+	return s.sc
+}
+
+// SetAttribute records a string, bool, integer or float value under key.
+func (s *Span) SetAttribute(key string, value any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ended {
+		return
+	}
+
+	if s.data.Attributes == nil {
+		s.data.Attributes = make(map[string]any)
+	}
+
+	s.data.Attributes[key] = value
+}
+
+// AddEvent records something that happened at a point of the span, attrs are key and value pairs.
+func (s *Span) AddEvent(name string, attrs ...any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ended {
+		return
+	}
+
+	event := Event{Name: name, Time: time.Now()}
+
+	for i := 0; i+1 < len(attrs); i += 2 {
+		key, ok := attrs[i].(string)
+		if !ok {
+			continue
+		}
+
+		if event.Attributes == nil {
+			event.Attributes = make(map[string]any)
+		}
+
+		event.Attributes[key] = attrs[i+1]
+	}
+
+	s.data.Events = append(s.data.Events, event)
+}
+
+func (s *Span) SetStatus(code StatusCode, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ended {
+		return
+	}
+
+	s.data.Status = code
+	s.data.Message = message
+}
+
+// End finishes the span and hands it to the exporters of its tracer, only the first call counts.
+func (s *Span) End() {
+	s.mu.Lock()
+
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+
+	s.ended = true
+	s.data.End = time.Now()
+	data := s.data
+
+	s.mu.Unlock()
+
+	if s.sc.Sampled() {
+		s.tracer.export(&data)
+	}
+}
@@ -0,0 +1,171 @@
+package tracing
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// Exporter sends ended spans somewhere, see NewJSONExporter and NewOTLPExporter.
+type Exporter interface {
+	Export(ctx context.Context, spans []*SpanData) error
+	Shutdown(ctx context.Context) error
+}
+
+type TracerSettings struct {
+	// Service names the client in the exported spans.
+	Service string
+	// BatchSize and FlushInterval bound how long spans wait before being exported.
+	BatchSize     int
+	FlushInterval time.Duration
+}
+
+// Tracer starts spans and exports them in batches from a background goroutine.
+type Tracer struct {
+	settings  TracerSettings
+	exporters []Exporter
+
+	spans chan *SpanData
+	flush chan chan struct{}
+	done  chan struct{}
+	once  sync.Once
+}
+
+func NewTracer(settings TracerSettings, exporters ...Exporter) *Tracer {
+	if settings.BatchSize <= 0 {
+		settings.BatchSize = 64
+	}
+
+	if settings.FlushInterval <= 0 {
+		settings.FlushInterval = 2 * time.Second
+	}
+
+	t := &Tracer{
+		settings:  settings,
+		exporters: exporters,
+		spans:     make(chan *SpanData, 4*settings.BatchSize),
+		flush:     make(chan chan struct{}),
+		done:      make(chan struct{}),
+	}
+
+	go t.run()
+
+	return t
+}
+
+// Start begins a span that is a child of the span or remote parent of ctx, or a new trace root.
+func (t *Tracer) Start(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	parent := SpanContextFromContext(ctx)
+
+	sc := SpanContext{SpanID: newSpanID()}
+	if parent.IsValid() {
+		sc.TraceID = parent.TraceID
+		sc.Flags = parent.Flags
+		sc.TraceState = parent.TraceState
+	} else {
+		sc.TraceID = newTraceID()
+		sc.Flags = FlagSampled
+	}
+
+	span := &Span{
+		tracer: t,
+		sc:     sc,
+		data: SpanData{
+			Name:       name,
+			Kind:       kind,
+			TraceID:    sc.TraceID.String(),
+			SpanID:     sc.SpanID.String(),
+			TraceState: sc.TraceState,
+			Start:      time.Now(),
+			Status:     StatusUnset,
+			Service:    t.settings.Service,
+		},
+	}
+
+	if parent.IsValid() {
+		span.data.ParentID = parent.SpanID.String()
+	}
+
+	return ContextWithSpan(ctx, span), span
+}
+
+func (t *Tracer) export(span *SpanData) {
+	select {
+	case t.spans <- span:
+	case <-t.done:
+	default:
+		// the exporters are behind, tracing must not slow the calls down
+		log.Println("Dropping span", span.Name, "of trace", span.TraceID)
+	}
+}
+
+func (t *Tracer) run() {
+	ticker := time.NewTicker(t.settings.FlushInterval)
+	defer ticker.Stop()
+
+	var batch []*SpanData
+
+	send := func() {
+		if len(batch) == 0 {
+			return
+		}
+
+		for _, e := range t.exporters {
+			if err := e.Export(context.Background(), batch); err != nil {
+				log.Println("Failed to export spans: ", err)
+			}
+		}
+
+		batch = nil
+	}
+
+	for {
+		select {
+		case span := <-t.spans:
+			batch = append(batch, span)
+			if len(batch) >= t.settings.BatchSize {
+				send()
+			}
+		case <-ticker.C:
+			send()
+		case flushed := <-t.flush:
+			for len(t.spans) > 0 {
+				batch = append(batch, <-t.spans)
+			}
+
+			send()
+			close(flushed)
+		case <-t.done:
+			return
+		}
+	}
+}
+
+// Shutdown exports the pending spans and shuts the exporters down. Spans ended afterwards are dropped.
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	var err error
+
+	t.once.Do(func() {
+		flushed := make(chan struct{})
+
+		select {
+		case t.flush <- flushed:
+			select {
+			case <-flushed:
+			case <-ctx.Done():
+			}
+		case <-ctx.Done():
+		}
+
+		close(t.done)
+
+		for _, e := range t.exporters {
+			if shutdownErr := e.Shutdown(ctx); shutdownErr != nil && err == nil {
+				err = shutdownErr
+			}
+		}
+	})
+
+	return err
+}